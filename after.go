@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RequestInfo describes a completed call for WithAfterRequest.
+type RequestInfo struct {
+	Method string
+	URL    string
+	// StatusCode is the final response's status, or 0 if there was none.
+	StatusCode int
+	// Attempts is the number of tries made, counting retries.
+	Attempts int
+	// Bytes is the number of response body bytes read by the caller.
+	Bytes int64
+	// Duration runs from the call to Do until the body was read to the end
+	// or closed, or until Do failed.
+	Duration time.Duration
+	// Err is the error that ended the call, if any.
+	Err error
+}
+
+// WithAfterRequest calls fn exactly once for every call to Do, after it
+// completes: when Do fails, or else once the response body is read to the
+// end, fails, or is closed. Retries are aggregated into one call. fn only
+// observes; it cannot change the outcome.
+func WithAfterRequest(fn func(ctx context.Context, info RequestInfo)) Option {
+	return func(c *DefaultClient) {
+		c.afterRequest = fn
+	}
+}
+
+type attemptsKey struct{}
+
+// countAttempt records that req's call has made attempt tries.
+func countAttempt(req *http.Request, attempt int) {
+	if n, ok := req.Context().Value(attemptsKey{}).(*int); ok {
+		*n = attempt
+	}
+}
+
+// observe runs do for req, reporting the call to the after request hook.
+func (c *DefaultClient) observe(req *http.Request, do func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	if c.afterRequest == nil {
+		return do(req)
+	}
+	start := time.Now()
+	ctx := req.Context()
+	attempts := new(int)
+	info := RequestInfo{Method: req.Method, URL: req.URL.String()}
+	report := func(err error) {
+		info.Attempts = *attempts
+		info.Duration = time.Since(start)
+		info.Err = err
+		c.afterRequest(ctx, info)
+	}
+
+	resp, err := do(req.WithContext(context.WithValue(ctx, attemptsKey{}, attempts)))
+	if err != nil {
+		report(err)
+		return nil, err
+	}
+	info.StatusCode = resp.StatusCode
+	resp.Body = &afterBody{rc: resp.Body, bytes: &info.Bytes, report: report}
+	return resp, nil
+}
+
+// afterBody counts the bytes read and reports once the body is done.
+type afterBody struct {
+	rc     io.ReadCloser
+	bytes  *int64
+	report func(err error)
+	once   sync.Once
+}
+
+func (b *afterBody) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	*b.bytes += int64(n)
+	if err == io.EOF {
+		b.once.Do(func() { b.report(nil) })
+	} else if err != nil {
+		b.once.Do(func() { b.report(err) })
+	}
+	return n, err
+}
+
+func (b *afterBody) Close() error {
+	err := b.rc.Close()
+	b.once.Do(func() { b.report(nil) })
+	return err
+}
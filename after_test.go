@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWithAfterRequest(t *testing.T) {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			if calls.Add(1) <= 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, "hello")
+	}))
+	defer srv.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedURL := closed.URL
+	closed.Close()
+
+	errEdit := errors.New("edit failed")
+	tests := []struct {
+		name    string
+		url     string
+		opts    []Option
+		want    RequestInfo
+		wantErr bool
+	}{
+		{
+			name: "retried call reported once",
+			url:  srv.URL + "/flaky",
+			want: RequestInfo{Method: http.MethodGet, StatusCode: http.StatusOK, Attempts: 3, Bytes: 5},
+		},
+		{
+			name:    "error status",
+			url:     srv.URL + "/missing",
+			want:    RequestInfo{Method: http.MethodGet, StatusCode: http.StatusNotFound, Attempts: 1},
+			wantErr: true,
+		},
+		{
+			name:    "connection failure",
+			url:     closedURL,
+			want:    RequestInfo{Method: http.MethodGet, Attempts: 3},
+			wantErr: true,
+		},
+		{
+			name:    "request editor failure",
+			url:     srv.URL,
+			opts:    []Option{WithRequestEditors(func(context.Context, *http.Request) error { return errEdit })},
+			want:    RequestInfo{Method: http.MethodGet},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu    sync.Mutex
+				infos []RequestInfo
+			)
+			hook := WithAfterRequest(func(ctx context.Context, info RequestInfo) {
+				mu.Lock()
+				defer mu.Unlock()
+				infos = append(infos, info)
+			})
+			c := NewDefaultClient(append(tt.opts, WithRetry(3, fastBackoff), hook)...)
+			_, err := FetchDataFrom(c, tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchDataFrom() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(infos) != 1 {
+				t.Fatalf("hook ran %d times, want 1", len(infos))
+			}
+			got := infos[0]
+			if got.URL != tt.url {
+				t.Errorf("URL = %q, want %q", got.URL, tt.url)
+			}
+			if got.Duration <= 0 {
+				t.Errorf("Duration = %v, want > 0", got.Duration)
+			}
+			if (got.Err != nil) != (tt.want.StatusCode == 0) {
+				t.Errorf("Err = %v, want an error only when there was no response", got.Err)
+			}
+			got.URL, got.Duration, got.Err = "", 0, nil
+			if got != tt.want {
+				t.Errorf("info = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"time"
+)
+
+// MaxAsyncPolls bounds how many times FetchAsync polls before giving up.
+const MaxAsyncPolls = 100
+
+// ErrPollLimitExceeded is returned when an asynchronous job is still pending
+// after MaxAsyncPolls polls.
+var ErrPollLimitExceeded = errors.New("poll limit exceeded")
+
+// FetchAsync fetches url and, while the server answers 202 Accepted, polls
+// the Location it points to every pollInterval until the result arrives
+// with a 200. Any other status is returned as an *HTTPError.
+func FetchAsync(ctx context.Context, client HTTPClient, url string, pollInterval time.Duration) ([]byte, error) {
+	body, err := fetchAsync(ctx, client, url, pollInterval)
+	if err != nil {
+		return nil, mapError(client, err)
+	}
+	return body, nil
+}
+
+func fetchAsync(ctx context.Context, client HTTPClient, url string, pollInterval time.Duration) ([]byte, error) {
+	target := url
+	for polls := 0; ; polls++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		resp, err := send(client, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch data: %w", err)
+		}
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+			defer resp.Body.Close()
+			body, err := readBody(client, resp.Body)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read response body: %w", err)
+			}
+			return body, nil
+		case http.StatusAccepted:
+			drainBody(resp.Body)
+			if loc := resp.Header.Get("Location"); loc != "" {
+				if target, err = resolveLocation(target, loc); err != nil {
+					return nil, fmt.Errorf("invalid Location header: %w", err)
+				}
+			}
+		default:
+			defer resp.Body.Close()
+			return nil, statusError(client, resp)
+		}
+
+		if polls >= MaxAsyncPolls {
+			return nil, ErrPollLimitExceeded
+		}
+		if err := sleep(ctx, pollInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func resolveLocation(base, loc string) (string, error) {
+	b, err := neturl.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	l, err := neturl.Parse(loc)
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(l).String(), nil
+}
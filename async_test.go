@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchAsync(t *testing.T) {
+	var polls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jobs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/jobs/1")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/jobs/1", func(w http.ResponseWriter, r *http.Request) {
+		if polls.Add(1) < 2 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Write([]byte(`{"status":"done"}`))
+	})
+	mux.HandleFunc("/jobs/failed", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	body, err := FetchAsync(context.Background(), NewDefaultClient(), srv.URL+"/jobs", time.Millisecond)
+	if err != nil {
+		t.Fatalf("FetchAsync() error = %v", err)
+	}
+	if string(body) != `{"status":"done"}` {
+		t.Errorf("FetchAsync() = %q, want %q", body, `{"status":"done"}`)
+	}
+	if got := polls.Load(); got != 2 {
+		t.Errorf("polls = %d, want 2", got)
+	}
+
+	_, err = FetchAsync(context.Background(), NewDefaultClient(), srv.URL+"/jobs/failed", time.Millisecond)
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("FetchAsync() error = %v, want HTTPError 500", err)
+	}
+}
+
+func TestFetchAsync_Cancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := FetchAsync(ctx, NewDefaultClient(), srv.URL, 10*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("FetchAsync() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestFetchAsync_PollLimit(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	_, err := FetchAsync(context.Background(), NewDefaultClient(), srv.URL, 0)
+	if !errors.Is(err, ErrPollLimitExceeded) {
+		t.Errorf("FetchAsync() error = %v, want ErrPollLimitExceeded", err)
+	}
+	if got := requests.Load(); got != MaxAsyncPolls+1 {
+		t.Errorf("requests = %d, want %d", got, MaxAsyncPolls+1)
+	}
+}
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"sync"
+	"time"
+)
+
+// WithLoadBalancer spreads requests round-robin across the given base URLs
+// (e.g. "http://10.0.0.1:8080"), replacing the scheme and host of each
+// request. Retries pick the next backend. An invalid URL makes every request
+// fail with ErrInvalidOption.
+func WithLoadBalancer(backends ...string) Option {
+	return func(c *DefaultClient) {
+		lb := &balancer{now: time.Now}
+		for _, b := range backends {
+			u, err := neturl.Parse(b)
+			if err != nil || u.Host == "" {
+				c.invalidOption(fmt.Errorf("load balancer backend %q", b))
+				continue
+			}
+			lb.backends = append(lb.backends, &backend{scheme: u.Scheme, host: u.Host})
+		}
+		if c.balancer != nil {
+			lb.ejection = c.balancer.ejection
+		}
+		c.balancer = lb
+	}
+}
+
+// WithOutlierEjection enables passive health checking for the load
+// balancer: a backend whose error rate over its last window requests
+// exceeds maxErrorRate is taken out of rotation for cooldown, then
+// reinstated once a single probe request to it succeeds.
+func WithOutlierEjection(maxErrorRate float64, window int, cooldown time.Duration) Option {
+	return func(c *DefaultClient) {
+		if c.balancer == nil {
+			c.balancer = &balancer{now: time.Now}
+		}
+		c.balancer.ejection = &ejection{maxErrorRate: maxErrorRate, window: window, cooldown: cooldown}
+	}
+}
+
+type ejection struct {
+	maxErrorRate float64
+	window       int
+	cooldown     time.Duration
+}
+
+type balancer struct {
+	backends []*backend
+	ejection *ejection
+	now      func() time.Time
+
+	mu   sync.Mutex
+	next int
+}
+
+type backend struct {
+	scheme string
+	host   string
+
+	outcomes  []bool // ring of recent results, true on failure
+	pos       int
+	ejected   bool
+	ejectedAt time.Time
+	probing   bool
+}
+
+// pick returns the next backend in rotation, skipping ejected ones. When
+// every backend is ejected it falls back to plain round-robin.
+func (lb *balancer) pick() *backend {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	n := len(lb.backends)
+	for i := 0; i < n; i++ {
+		b := lb.backends[(lb.next+i)%n]
+		if lb.available(b) {
+			lb.next = (lb.next + i + 1) % n
+			return b
+		}
+	}
+	b := lb.backends[lb.next%n]
+	lb.next = (lb.next + 1) % n
+	return b
+}
+
+func (lb *balancer) available(b *backend) bool {
+	if !b.ejected {
+		return true
+	}
+	if b.probing || lb.now().Sub(b.ejectedAt) < lb.ejection.cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (lb *balancer) record(b *backend, failed bool) {
+	if lb.ejection == nil {
+		return
+	}
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	if b.probing {
+		b.probing = false
+		if failed {
+			b.ejectedAt = lb.now()
+			return
+		}
+		b.ejected = false
+		b.outcomes, b.pos = nil, 0
+	}
+
+	if len(b.outcomes) < lb.ejection.window {
+		b.outcomes = append(b.outcomes, failed)
+	} else {
+		b.outcomes[b.pos] = failed
+		b.pos = (b.pos + 1) % lb.ejection.window
+	}
+	if len(b.outcomes) < lb.ejection.window {
+		return
+	}
+
+	failures := 0
+	for _, f := range b.outcomes {
+		if f {
+			failures++
+		}
+	}
+	if float64(failures)/float64(len(b.outcomes)) > lb.ejection.maxErrorRate {
+		b.ejected = true
+		b.ejectedAt = lb.now()
+	}
+}
+
+// route sends req to a backend chosen by the load balancer, if configured.
+func (c *DefaultClient) route(req *http.Request, try func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	if c.balancer == nil || len(c.balancer.backends) == 0 {
+		return try(req)
+	}
+
+	b := c.balancer.pick()
+	req = req.Clone(req.Context())
+	req.URL.Scheme = b.scheme
+	req.URL.Host = b.host
+	req.Host = ""
+
+	resp, err := try(req)
+	c.balancer.record(b, err != nil || resp.StatusCode >= 500)
+	return resp, err
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type backendServer struct {
+	*httptest.Server
+	hits    atomic.Int32
+	healthy atomic.Bool
+}
+
+func newBackendServer(t *testing.T, healthy bool) *backendServer {
+	t.Helper()
+	b := &backendServer{}
+	b.healthy.Store(healthy)
+	b.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b.hits.Add(1)
+		if !b.healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(b.Close)
+	return b
+}
+
+func TestWithLoadBalancer(t *testing.T) {
+	a := newBackendServer(t, true)
+	b := newBackendServer(t, true)
+
+	c := NewDefaultClient(WithLoadBalancer(a.URL, b.URL))
+	for i := 0; i < 4; i++ {
+		if _, err := FetchDataFrom(c, "http://api.internal/posts"); err != nil {
+			t.Fatalf("FetchDataFrom() error = %v", err)
+		}
+	}
+
+	if a.hits.Load() != 2 || b.hits.Load() != 2 {
+		t.Errorf("hits = %d/%d, want 2/2", a.hits.Load(), b.hits.Load())
+	}
+}
+
+func TestWithLoadBalancer_InvalidBackend(t *testing.T) {
+	a := newBackendServer(t, true)
+
+	c := NewDefaultClient(WithLoadBalancer(a.URL, "10.0.0.1:8080"))
+	_, err := FetchDataFrom(c, "http://api.internal/posts")
+	if !errors.Is(err, ErrInvalidOption) || !strings.Contains(err.Error(), "10.0.0.1:8080") {
+		t.Errorf("FetchDataFrom() error = %v, want ErrInvalidOption naming the backend", err)
+	}
+	if got := a.hits.Load(); got != 0 {
+		t.Errorf("valid backend hits = %d, want 0", got)
+	}
+}
+
+func TestWithOutlierEjection(t *testing.T) {
+	good := newBackendServer(t, true)
+	bad := newBackendServer(t, false)
+
+	c := NewDefaultClient(
+		WithLoadBalancer(good.URL, bad.URL),
+		WithOutlierEjection(0.5, 2, time.Minute),
+	)
+	now := time.Now()
+	c.balancer.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		FetchDataFrom(c, "http://api.internal/posts")
+	}
+	if got := bad.hits.Load(); got != 2 {
+		t.Fatalf("bad backend hits = %d, want 2 before ejection", got)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := FetchDataFrom(c, "http://api.internal/posts"); err != nil {
+			t.Fatalf("FetchDataFrom() after ejection error = %v", err)
+		}
+	}
+	if got := bad.hits.Load(); got != 2 {
+		t.Errorf("bad backend hits = %d, want no traffic while ejected", got)
+	}
+
+	// After the cooldown a probe is let through; it fails so the backend
+	// stays ejected.
+	now = now.Add(2 * time.Minute)
+	for i := 0; i < 3; i++ {
+		FetchDataFrom(c, "http://api.internal/posts")
+	}
+	if got := bad.hits.Load(); got != 3 {
+		t.Fatalf("bad backend hits = %d, want exactly one failed probe", got)
+	}
+
+	// Once healthy, the next probe after another cooldown reinstates it.
+	bad.healthy.Store(true)
+	now = now.Add(2 * time.Minute)
+	for i := 0; i < 4; i++ {
+		if _, err := FetchDataFrom(c, "http://api.internal/posts"); err != nil {
+			t.Fatalf("FetchDataFrom() after recovery error = %v", err)
+		}
+	}
+	if got := bad.hits.Load(); got < 5 {
+		t.Errorf("bad backend hits = %d, want it back in rotation", got)
+	}
+}
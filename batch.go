@@ -0,0 +1,164 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Result is the outcome of a single item in a batch. Body holds the response
+// body on success; Err is set on failure.
+type Result struct {
+	Body []byte
+	Err  error
+}
+
+// PostBatch POSTs each payload to url as JSON, with at most maxConcurrency
+// requests in flight, and returns one Result per payload in input order.
+// Non-2xx responses are reported as *HTTPError. Once ctx is done, items not
+// yet started fail with the context error, which PostBatch also returns.
+func PostBatch(ctx context.Context, client HTTPClient, url string, payloads []any, maxConcurrency int) ([]Result, error) {
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+
+	results := make([]Result, len(payloads))
+	sem := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+	for i, payload := range payloads {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			for j := i; j < len(payloads); j++ {
+				results[j].Err = ctx.Err()
+			}
+			wg.Wait()
+			return results, ctx.Err()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			body, err := postJSON(ctx, client, url, payload)
+			if err != nil {
+				err = mapError(client, err)
+			}
+			results[i] = Result{Body: body, Err: err}
+		}()
+	}
+	wg.Wait()
+	return results, ctx.Err()
+}
+
+// FetchBatch GETs every url, with at most maxConcurrency requests in flight,
+// and returns one Result per url in input order. Repeated URLs share a
+// single request, whose response also goes through the client's cache.
+// Non-2xx responses are reported as *HTTPError. Once ctx is done, URLs not
+// yet started fail with the context error, which FetchBatch also returns.
+func FetchBatch(ctx context.Context, client HTTPClient, urls []string, maxConcurrency int) ([]Result, error) {
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+
+	// Group the input indices by URL, in order of first appearance.
+	var distinct []string
+	indices := make(map[string][]int)
+	for i, url := range urls {
+		if _, ok := indices[url]; !ok {
+			distinct = append(distinct, url)
+		}
+		indices[url] = append(indices[url], i)
+	}
+
+	results := make([]Result, len(urls))
+	fill := func(url string, body []byte, err error) {
+		for n, i := range indices[url] {
+			if n > 0 && body != nil {
+				body = bytes.Clone(body)
+			}
+			results[i] = Result{Body: body, Err: err}
+		}
+	}
+	sem := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+	for i, url := range distinct {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			for _, url := range distinct[i:] {
+				fill(url, nil, ctx.Err())
+			}
+			wg.Wait()
+			return results, ctx.Err()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			var body []byte
+			resp, err := fetch(ctx, client, url)
+			if err != nil {
+				err = mapError(client, err)
+			} else {
+				body = resp.Body
+			}
+			fill(url, body, err)
+		}()
+	}
+	wg.Wait()
+	return results, ctx.Err()
+}
+
+// BatchResult is the outcome of a single URL in FetchBatchJSON. Value holds
+// the decoded body on success; Err is set on failure.
+type BatchResult[T any] struct {
+	Value T
+	Err   error
+}
+
+// FetchBatchJSON fetches urls like FetchBatch and decodes each JSON body
+// into a T. A body that fails to decode only fails its own result.
+func FetchBatchJSON[T any](ctx context.Context, client HTTPClient, urls []string, maxConcurrency int) ([]BatchResult[T], error) {
+	raw, err := FetchBatch(ctx, client, urls, maxConcurrency)
+	results := make([]BatchResult[T], len(raw))
+	for i, r := range raw {
+		if r.Err != nil {
+			results[i].Err = r.Err
+			continue
+		}
+		if err := json.Unmarshal(r.Body, &results[i].Value); err != nil {
+			results[i].Err = mapError(client, fmt.Errorf("failed to decode response: %w", err))
+		}
+	}
+	return results, err
+}
+
+func postJSON(ctx context.Context, client HTTPClient, url string, payload any) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := send(client, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, statusError(client, resp)
+	}
+	body, err := readBody(client, resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,241 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPostBatch(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		var item struct{ ID int }
+		if err := json.NewDecoder(r.Body).Decode(&item); err != nil || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if item.ID%2 == 0 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		json.NewEncoder(w).Encode(item)
+	}))
+	defer srv.Close()
+
+	payloads := []any{
+		map[string]int{"id": 1},
+		map[string]int{"id": 2},
+		map[string]int{"id": 3},
+		map[string]int{"id": 4},
+		map[string]int{"id": 5},
+		func() {},
+	}
+	results, err := PostBatch(context.Background(), NewDefaultClient(), srv.URL, payloads, 2)
+	if err != nil {
+		t.Fatalf("PostBatch() error = %v", err)
+	}
+	if len(results) != len(payloads) {
+		t.Fatalf("len(results) = %d, want %d", len(results), len(payloads))
+	}
+
+	for i, res := range results[:5] {
+		id := i + 1
+		if id%2 == 0 {
+			var httpErr *HTTPError
+			if !errors.As(res.Err, &httpErr) || httpErr.StatusCode != http.StatusUnprocessableEntity {
+				t.Errorf("results[%d].Err = %v, want 422 HTTPError", i, res.Err)
+			}
+			continue
+		}
+		if res.Err != nil {
+			t.Errorf("results[%d].Err = %v", i, res.Err)
+			continue
+		}
+		var got struct{ ID int }
+		json.Unmarshal(res.Body, &got)
+		if got.ID != id {
+			t.Errorf("results[%d] echoed id %d, want %d", i, got.ID, id)
+		}
+	}
+	if results[5].Err == nil {
+		t.Error("results[5].Err = nil, want encoding error")
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("peak concurrency = %d, want <= 2", p)
+	}
+}
+
+func TestPostBatch_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			cancel()
+		}
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	payloads := []any{1, 2, 3, 4}
+	results, err := PostBatch(ctx, NewDefaultClient(), srv.URL, payloads, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("PostBatch() error = %v, want context.Canceled", err)
+	}
+	for i, res := range results {
+		if !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("results[%d].Err = %v, want context.Canceled", i, res.Err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("server saw %d requests, want 1", n)
+	}
+}
+
+func TestFetchBatch_Dedup(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls[r.URL.Path]++
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer srv.Close()
+
+	c := NewDefaultClient(WithCache(NewMemoryCache()))
+	paths := []string{"/a", "/b", "/a", "/missing", "/a", "/b", "/missing"}
+	urls := make([]string, len(paths))
+	for i, p := range paths {
+		urls[i] = srv.URL + p
+	}
+
+	results, err := FetchBatch(context.Background(), c, urls, 4)
+	if err != nil {
+		t.Fatalf("FetchBatch() error = %v", err)
+	}
+	for i, p := range paths {
+		if p == "/missing" {
+			var herr *HTTPError
+			if !errors.As(results[i].Err, &herr) || herr.StatusCode != http.StatusNotFound {
+				t.Errorf("result %d err = %v, want a 404 *HTTPError", i, results[i].Err)
+			}
+			continue
+		}
+		if results[i].Err != nil || string(results[i].Body) != p {
+			t.Errorf("result %d = %q, %v, want %q", i, results[i].Body, results[i].Err, p)
+		}
+	}
+	results[0].Body[0] = 'x'
+	if string(results[2].Body) != "/a" {
+		t.Error("duplicate results share a body slice")
+	}
+	want := map[string]int{"/a": 1, "/b": 1, "/missing": 1}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("network calls = %v, want %v", calls, want)
+	}
+
+	// The batch populated the cache, so a repeat of the cacheable URLs
+	// stays off the network.
+	if _, err := FetchBatch(context.Background(), c, urls[:3], 4); err != nil {
+		t.Fatalf("FetchBatch() repeat error = %v", err)
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("network calls after cached batch = %v, want %v", calls, want)
+	}
+}
+
+func TestFetchBatch_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := FetchBatch(ctx, NewDefaultClient(), []string{"http://example.invalid/a", "http://example.invalid/a"}, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	for i, r := range results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("result %d err = %v, want context.Canceled", i, r.Err)
+		}
+	}
+}
+
+func TestFetchBatchJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bad":
+			w.Write([]byte(`[{"id":`))
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			fmt.Fprintf(w, `[{"id":1,"title":%q}]`, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	urls := []string{srv.URL + "/a", srv.URL + "/bad", srv.URL + "/b", srv.URL + "/missing"}
+	results, err := FetchBatchJSON[[]Post](context.Background(), NewDefaultClient(), urls, 2)
+	if err != nil {
+		t.Fatalf("FetchBatchJSON() error = %v", err)
+	}
+	if len(results) != len(urls) {
+		t.Fatalf("got %d results, want %d", len(results), len(urls))
+	}
+	for i, path := range []string{"/a", "", "/b", ""} {
+		r := results[i]
+		if path == "" {
+			continue
+		}
+		if r.Err != nil {
+			t.Errorf("result %d err = %v", i, r.Err)
+		} else if want := []Post{{ID: 1, Title: path}}; !reflect.DeepEqual(r.Value, want) {
+			t.Errorf("result %d = %+v, want %+v", i, r.Value, want)
+		}
+	}
+	var syntaxErr *json.SyntaxError
+	if !errors.As(results[1].Err, &syntaxErr) {
+		t.Errorf("malformed result err = %v, want a *json.SyntaxError", results[1].Err)
+	}
+	var herr *HTTPError
+	if !errors.As(results[3].Err, &herr) || herr.StatusCode != http.StatusNotFound {
+		t.Errorf("missing result err = %v, want a 404 *HTTPError", results[3].Err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err = FetchBatchJSON[[]Post](ctx, NewDefaultClient(), urls, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	for i, r := range results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("cancelled result %d err = %v, want context.Canceled", i, r.Err)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending the request while the circuit
+// breaker for the request's host is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Breaker is a per-host circuit breaker. After threshold consecutive
+// failures (transport errors or 5xx responses) the circuit for that host
+// opens and requests fail fast with ErrCircuitOpen. Once cooldown has
+// elapsed a single trial request is let through; its outcome closes or
+// re-opens the circuit. A Breaker is safe for concurrent use and may be
+// shared by several clients.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	latency   *latencyTrip
+	now       func() time.Time
+
+	mu    sync.Mutex
+	hosts map[string]*circuit
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type circuit struct {
+	state    circuitState
+	failures int
+	openedAt time.Time
+
+	slow []bool // ring of recent requests, true when over the latency limit
+	pos  int
+}
+
+// BreakerOption configures a Breaker.
+type BreakerOption func(*Breaker)
+
+// TripOnLatency also opens a host's circuit when more than maxSlowRate of
+// its last window requests took longer than slow to return headers, even if
+// they succeeded. A slow trial request re-opens the circuit.
+func TripOnLatency(slow time.Duration, maxSlowRate float64, window int) BreakerOption {
+	return func(b *Breaker) {
+		b.latency = &latencyTrip{slow: slow, maxSlowRate: maxSlowRate, window: window}
+	}
+}
+
+type latencyTrip struct {
+	slow        time.Duration
+	maxSlowRate float64
+	window      int
+}
+
+// NewBreaker returns a Breaker that opens after threshold consecutive
+// failures and retries after cooldown.
+func NewBreaker(threshold int, cooldown time.Duration, opts ...BreakerOption) *Breaker {
+	b := &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		hosts:     make(map[string]*circuit),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// WithCircuitBreaker gives the client its own Breaker.
+func WithCircuitBreaker(threshold int, cooldown time.Duration, opts ...BreakerOption) Option {
+	return WithSharedBreaker(NewBreaker(threshold, cooldown, opts...))
+}
+
+// WithSharedBreaker uses b for the client, so failure state is shared with
+// every other client configured with the same Breaker.
+func WithSharedBreaker(b *Breaker) Option {
+	return func(c *DefaultClient) {
+		c.breaker = b
+	}
+}
+
+func (b *Breaker) allow(host string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cb := b.circuit(host)
+	switch cb.state {
+	case circuitOpen:
+		if b.now().Sub(cb.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		cb.state = circuitHalfOpen
+		return nil
+	case circuitHalfOpen:
+		// A trial request is already in flight.
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+func (b *Breaker) record(host string, resp *http.Response, err error, elapsed time.Duration) {
+	failed := err != nil || resp.StatusCode >= 500
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cb := b.circuit(host)
+	if b.latency != nil {
+		isSlow := elapsed > b.latency.slow
+		if (cb.state == circuitHalfOpen && isSlow) || cb.tooSlow(b.latency, isSlow) {
+			cb.state = circuitOpen
+			cb.openedAt = b.now()
+			cb.slow, cb.pos = nil, 0
+			return
+		}
+	}
+	if !failed {
+		cb.state = circuitClosed
+		cb.failures = 0
+		return
+	}
+	cb.failures++
+	if cb.state == circuitHalfOpen || cb.failures >= b.threshold {
+		cb.state = circuitOpen
+		cb.openedAt = b.now()
+	}
+}
+
+// tooSlow records a request and reports whether the share of slow requests
+// over a full window exceeds the limit.
+func (cb *circuit) tooSlow(trip *latencyTrip, isSlow bool) bool {
+	if trip.window <= 0 {
+		return false
+	}
+	if len(cb.slow) < trip.window {
+		cb.slow = append(cb.slow, isSlow)
+	} else {
+		cb.slow[cb.pos] = isSlow
+		cb.pos = (cb.pos + 1) % trip.window
+	}
+	if len(cb.slow) < trip.window {
+		return false
+	}
+	var n int
+	for _, s := range cb.slow {
+		if s {
+			n++
+		}
+	}
+	return float64(n)/float64(trip.window) > trip.maxSlowRate
+}
+
+func (b *Breaker) circuit(host string) *circuit {
+	cb, ok := b.hosts[host]
+	if !ok {
+		cb = &circuit{}
+		b.hosts[host] = cb
+	}
+	return cb
+}
@@ -0,0 +1,159 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithSharedBreaker(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	breaker := NewBreaker(3, time.Minute)
+	a := NewDefaultClient(WithSharedBreaker(breaker))
+	b := NewDefaultClient(WithSharedBreaker(breaker))
+
+	for _, c := range []*DefaultClient{a, a, b} {
+		_, err := FetchDataFrom(c, srv.URL)
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) {
+			t.Fatalf("FetchDataFrom() error = %v, want HTTPError while circuit closed", err)
+		}
+	}
+
+	for name, c := range map[string]*DefaultClient{"a": a, "b": b} {
+		_, err := FetchDataFrom(c, srv.URL)
+		if !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("client %s error = %v, want ErrCircuitOpen", name, err)
+		}
+	}
+	if got := hits.Load(); got != 3 {
+		t.Errorf("server hits = %d, want 3", got)
+	}
+}
+
+func TestWithCircuitBreaker_NotShared(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	a := NewDefaultClient(WithCircuitBreaker(2, time.Minute))
+	b := NewDefaultClient(WithCircuitBreaker(2, time.Minute))
+
+	FetchDataFrom(a, srv.URL)
+	FetchDataFrom(b, srv.URL)
+
+	for name, c := range map[string]*DefaultClient{"a": a, "b": b} {
+		if _, err := FetchDataFrom(c, srv.URL); errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("client %s circuit open after a single failure of its own", name)
+		}
+	}
+}
+
+func TestBreaker_HalfOpen(t *testing.T) {
+	now := time.Now()
+	b := NewBreaker(1, time.Second)
+	b.now = func() time.Time { return now }
+
+	ok := &http.Response{StatusCode: http.StatusOK}
+	const host = "api.test"
+
+	b.record(host, nil, errors.New("connection refused"), 0)
+	if err := b.allow(host); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow() = %v, want ErrCircuitOpen", err)
+	}
+
+	now = now.Add(2 * time.Second)
+	if err := b.allow(host); err != nil {
+		t.Fatalf("allow() after cooldown = %v, want trial request", err)
+	}
+	if err := b.allow(host); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("allow() during trial = %v, want ErrCircuitOpen", err)
+	}
+
+	b.record(host, ok, nil, 0)
+	if err := b.allow(host); err != nil {
+		t.Errorf("allow() after successful trial = %v, want closed circuit", err)
+	}
+}
+
+func TestWithCircuitBreaker_TripOnLatency(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		time.Sleep(30 * time.Millisecond)
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	c := NewDefaultClient(WithCircuitBreaker(100, time.Minute, TripOnLatency(10*time.Millisecond, 0.5, 4)))
+	for i := 0; i < 4; i++ {
+		if _, err := FetchDataFrom(c, srv.URL); err != nil {
+			t.Fatalf("request %d error = %v, want slow success", i+1, err)
+		}
+	}
+	if _, err := FetchDataFrom(c, srv.URL); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("FetchDataFrom() error = %v, want ErrCircuitOpen after sustained latency", err)
+	}
+	if got := hits.Load(); got != 4 {
+		t.Errorf("server hits = %d, want 4", got)
+	}
+}
+
+func TestBreaker_TripOnLatency(t *testing.T) {
+	ok := &http.Response{StatusCode: http.StatusOK}
+	const (
+		host = "api.test"
+		slow = 200 * time.Millisecond
+		fast = 10 * time.Millisecond
+	)
+
+	tests := []struct {
+		name     string
+		samples  []time.Duration
+		wantOpen bool
+	}{
+		{
+			name:     "window not yet full",
+			samples:  []time.Duration{slow, slow, slow},
+			wantOpen: false,
+		},
+		{
+			name:     "slow rate at limit",
+			samples:  []time.Duration{slow, fast, slow, fast},
+			wantOpen: false,
+		},
+		{
+			name:     "slow rate over limit",
+			samples:  []time.Duration{slow, fast, slow, slow},
+			wantOpen: true,
+		},
+		{
+			name:     "only recent window counts",
+			samples:  []time.Duration{slow, slow, fast, fast, fast, slow},
+			wantOpen: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBreaker(100, time.Minute, TripOnLatency(100*time.Millisecond, 0.5, 4))
+			for _, d := range tt.samples {
+				b.record(host, ok, nil, d)
+			}
+			open := errors.Is(b.allow(host), ErrCircuitOpen)
+			if open != tt.wantOpen {
+				t.Errorf("circuit open = %v, want %v", open, tt.wantOpen)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package client
+
+import "sync"
+
+// WithRetryBudget caps retries across all requests with a token bucket, so
+// a widespread outage cannot multiply load on the backend. Every retry
+// spends one token and every successful attempt earns ratio tokens (0.1
+// allows one retry per ten successes). The bucket starts full with
+// maxTokens tokens and never holds more, which bounds the burst of retries
+// an outage can trigger; once it is empty, failed requests return without
+// retrying until successes refill it. It takes effect together with
+// WithRetry.
+func WithRetryBudget(ratio float64, maxTokens int) Option {
+	return func(c *DefaultClient) {
+		c.budget = &retryBudget{ratio: ratio, max: float64(maxTokens), tokens: float64(maxTokens)}
+	}
+}
+
+type retryBudget struct {
+	ratio, max float64
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+// deposit credits a successful attempt. A nil budget does nothing.
+func (b *retryBudget) deposit() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.tokens+b.ratio, b.max)
+}
+
+// withdraw spends a token for a retry, reporting whether one was available.
+// A nil budget always allows the retry.
+func (b *retryBudget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWithRetryBudget(t *testing.T) {
+	var failing atomic.Bool
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewDefaultClient(WithRetry(3, fastBackoff), WithRetryBudget(0.5, 2))
+	get := func() int64 {
+		t.Helper()
+		before := calls.Load()
+		resp, err := c.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		drainBody(resp.Body)
+		return calls.Load() - before
+	}
+
+	steps := []struct {
+		name      string
+		failing   bool
+		wantCalls int64
+	}{
+		{
+			name:      "budget covers retries",
+			failing:   true,
+			wantCalls: 3,
+		},
+		{
+			name:      "budget exhausted",
+			failing:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "success refills",
+			wantCalls: 1,
+		},
+		{
+			name:      "success refills more",
+			wantCalls: 1,
+		},
+		{
+			name:      "one retry earned",
+			failing:   true,
+			wantCalls: 2,
+		},
+		{
+			name:      "exhausted again",
+			failing:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "refill",
+			wantCalls: 1,
+		},
+		{
+			name:      "refill",
+			wantCalls: 1,
+		},
+		{
+			name:      "refill",
+			wantCalls: 1,
+		},
+		{
+			name:      "refill",
+			wantCalls: 1,
+		},
+		{
+			name:      "refill beyond cap",
+			wantCalls: 1,
+		},
+		{
+			name:      "retries resume up to cap",
+			failing:   true,
+			wantCalls: 3,
+		},
+	}
+	for _, step := range steps {
+		failing.Store(step.failing)
+		if got := get(); got != step.wantCalls {
+			t.Errorf("%s: server calls = %d, want %d", step.name, got, step.wantCalls)
+		}
+	}
+}
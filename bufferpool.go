@@ -0,0 +1,62 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// maxPooledBuffer bounds the buffers kept in the pool when no
+// WithMaxResponseBytes limit is configured.
+const maxPooledBuffer = 4 << 20
+
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// WithResponseBufferPool reads response bodies into pooled buffers to reduce
+// allocations under high request volume. Callers always receive their own
+// copy of the body.
+func WithResponseBufferPool() Option {
+	return func(c *DefaultClient) {
+		c.pooledBuffers = true
+	}
+}
+
+// bodyReader is implemented by clients that customize how bodies are read.
+type bodyReader interface {
+	readBody(r io.Reader) ([]byte, error)
+}
+
+func readBody(client HTTPClient, r io.Reader) ([]byte, error) {
+	if br, ok := client.(bodyReader); ok {
+		return br.readBody(r)
+	}
+	return io.ReadAll(r)
+}
+
+func (c *DefaultClient) readBody(r io.Reader) ([]byte, error) {
+	if !c.pooledBuffers {
+		return io.ReadAll(r)
+	}
+
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer c.releaseBuffer(buf)
+
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(buf.Bytes()), nil
+}
+
+func (c *DefaultClient) releaseBuffer(buf *bytes.Buffer) {
+	limit := int64(maxPooledBuffer)
+	if c.maxResponseBytes > 0 && c.maxResponseBytes < limit {
+		limit = c.maxResponseBytes
+	}
+	if int64(buf.Cap()) > limit {
+		return
+	}
+	bufferPool.Put(buf)
+}
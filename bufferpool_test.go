@@ -0,0 +1,87 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWithResponseBufferPool(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat(r.URL.Query().Get("v"), 4096)))
+	}))
+	defer srv.Close()
+
+	c := NewDefaultClient(WithResponseBufferPool())
+
+	const workers = 32
+	results := make([][]byte, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body, err := FetchDataFrom(c, fmt.Sprintf("%s?v=%c", srv.URL, 'a'+i%26))
+			if err != nil {
+				t.Errorf("FetchDataFrom() error = %v", err)
+				return
+			}
+			results[i] = body
+		}(i)
+	}
+	wg.Wait()
+
+	for i, body := range results {
+		want := bytes.Repeat([]byte{byte('a' + i%26)}, 4096)
+		if !bytes.Equal(body, want) {
+			t.Errorf("worker %d body corrupted: got prefix %q", i, body[:min(len(body), 16)])
+		}
+	}
+}
+
+func TestReadBody_ReturnsCopy(t *testing.T) {
+	c := NewDefaultClient(WithResponseBufferPool())
+
+	first, err := c.readBody(strings.NewReader("first"))
+	if err != nil {
+		t.Fatalf("readBody() error = %v", err)
+	}
+	if _, err := c.readBody(strings.NewReader("other")); err != nil {
+		t.Fatalf("readBody() error = %v", err)
+	}
+
+	if string(first) != "first" {
+		t.Errorf("first body = %q after buffer reuse, want %q", first, "first")
+	}
+}
+
+func BenchmarkReadBody(b *testing.B) {
+	payload := bytes.Repeat([]byte("x"), 64<<10)
+
+	for _, bc := range []struct {
+		name string
+		opts []Option
+	}{
+		{
+			name: "ReadAll",
+		},
+		{
+			name: "Pooled",
+			opts: []Option{WithResponseBufferPool()},
+		},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			c := NewDefaultClient(bc.opts...)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.readBody(bytes.NewReader(payload)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
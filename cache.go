@@ -0,0 +1,491 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores serialized responses. Implementations must be safe for
+// concurrent use.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, val []byte, ttl time.Duration)
+	Delete(key string)
+}
+
+// WithCache caches successful GET responses in cache for as long as their
+// Cache-Control max-age allows. Responses marked no-store or no-cache are
+// never cached. Stale entries with an ETag or Last-Modified are revalidated
+// with a conditional request and reused on 304 Not Modified.
+func WithCache(cache Cache) Option {
+	return func(c *DefaultClient) {
+		c.cache = cache
+	}
+}
+
+// WithCacheKeyFunc makes WithCache key entries by fn(req) instead of the
+// request URL, e.g. to ignore a volatile query parameter. Requests for
+// which fn returns "" bypass the cache.
+func WithCacheKeyFunc(fn func(req *http.Request) string) Option {
+	return func(c *DefaultClient) {
+		c.cacheKey = fn
+	}
+}
+
+// WithNegativeCache makes WithCache also store error responses with the
+// given status codes, 404 Not Found if none are given, for ttl regardless
+// of their Cache-Control max-age, so a backend is not asked again and again
+// for a resource known to be missing. Keep ttl short: until it expires, the
+// resource appearing goes unnoticed. Responses marked no-store are never
+// cached.
+func WithNegativeCache(ttl time.Duration, codes ...int) Option {
+	return func(c *DefaultClient) {
+		if len(codes) == 0 {
+			codes = []int{http.StatusNotFound}
+		}
+		c.negativeTTL = ttl
+		c.negativeCodes = make(map[int]bool, len(codes))
+		for _, code := range codes {
+			c.negativeCodes[code] = true
+		}
+	}
+}
+
+// immutableTTL is how long WithImmutableCache keeps immutable responses.
+const immutableTTL = 365 * 24 * time.Hour
+
+// WithImmutableCache makes WithCache keep successful responses marked
+// Cache-Control: immutable, such as fingerprinted assets, for a year
+// regardless of max-age, serving them without any revalidation.
+func WithImmutableCache() Option {
+	return func(c *DefaultClient) {
+		c.immutableCache = true
+	}
+}
+
+// WithFreshnessHeader makes WithCache take a successful response's TTL from
+// the named header, a whole number of seconds, overriding its Cache-Control
+// max-age. Responses without the header, or with a malformed value, follow
+// the normal rules; no-store is always honored.
+func WithFreshnessHeader(name string) Option {
+	return func(c *DefaultClient) {
+		c.freshnessHeader = name
+	}
+}
+
+func defaultCacheKey(req *http.Request) string {
+	return req.URL.String()
+}
+
+// PurgeCache removes every cached variant of url, so the next request for
+// it goes to the network. It is a no-op without WithCache.
+func (c *DefaultClient) PurgeCache(url string) {
+	if c.cached == nil {
+		return
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return
+	}
+	if key := c.cached.key(req); key != "" {
+		c.cached.purge(key)
+	}
+}
+
+// PurgeAll removes every entry this client has stored in its cache.
+func (c *DefaultClient) PurgeAll() {
+	if c.cached == nil {
+		return
+	}
+	c.cached.mu.Lock()
+	bases := make([]string, 0, len(c.cached.keys))
+	for base := range c.cached.keys {
+		bases = append(bases, base)
+	}
+	c.cached.mu.Unlock()
+	for _, base := range bases {
+		c.cached.purge(base)
+	}
+}
+
+// cachingTransport serves GET requests from a Cache and stores cacheable
+// responses on the way back. Responses with a Vary header are stored per
+// combination of the named request headers.
+type cachingTransport struct {
+	next  http.RoundTripper
+	cache Cache
+	key   func(req *http.Request) string
+	now   func() time.Time
+
+	// negativeTTL is how long responses with a status in negative are kept.
+	negativeTTL time.Duration
+	negative    map[int]bool
+	immutable   bool
+	// freshness names a header holding the TTL in seconds.
+	freshness string
+	// revalidate is how long stale entries with validators are kept.
+	revalidate time.Duration
+	// maxBody is the largest body that is stored; larger responses pass
+	// through uncached. Zero means no limit.
+	maxBody int64
+
+	// Index entries are dropped when a lookup misses, e.g. after the cache
+	// evicted the entry, and swept once past the TTL they were stored with,
+	// so URLs that are no longer cached do not accumulate.
+	mu      sync.Mutex
+	vary    map[string][]string             // base key -> Vary header names
+	keys    map[string]map[string]time.Time // base key -> cache keys of its variants -> expiry
+	sweepAt int                             // len(keys) that triggers the next sweep
+}
+
+// minIndexSweep is the smallest variant index size that is swept for
+// expired entries.
+const minIndexSweep = 64
+
+func newCachingTransport(next http.RoundTripper, cache Cache, key func(*http.Request) string) *cachingTransport {
+	if key == nil {
+		key = defaultCacheKey
+	}
+	return &cachingTransport{
+		next:    next,
+		cache:   cache,
+		key:     key,
+		now:     time.Now,
+		vary:    make(map[string][]string),
+		keys:    make(map[string]map[string]time.Time),
+		sweepAt: minIndexSweep,
+	}
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	base := t.key(req)
+	if base == "" {
+		return t.next.RoundTrip(req)
+	}
+	t.mu.Lock()
+	key := variantKey(base, t.vary[base], req.Header)
+	t.mu.Unlock()
+
+	now := t.now()
+	cached, storedAt, freshUntil := t.load(key, req)
+	if cached == nil {
+		t.forget(base, key)
+	}
+	if cached != nil && (freshUntil.IsZero() || now.Before(freshUntil)) {
+		var age time.Duration
+		if !storedAt.IsZero() {
+			age = now.Sub(storedAt)
+		}
+		reportFreshness(req, Freshness{FromCache: true, Age: age + headerAge(cached.Header)})
+		return cached, nil
+	}
+
+	// A stale entry is only still present if it carries validators, so
+	// ask the server whether it may be reused.
+	outReq := req
+	if cached != nil {
+		outReq = conditionalRequest(req, cached.Header)
+	}
+	resp, err := t.next.RoundTrip(outReq)
+	if cached != nil {
+		if err == nil && resp.StatusCode == http.StatusNotModified {
+			drainBody(resp.Body)
+			return t.refresh(base, req, cached, resp.Header)
+		}
+		cached.Body.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	reportFreshness(req, Freshness{Age: headerAge(resp.Header)})
+
+	ttl := t.ttl(resp)
+	names, ok := varyNames(resp.Header)
+	if ttl <= 0 || !ok {
+		return resp, nil
+	}
+
+	body, rest, err := t.readBody(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if rest != nil {
+		resp.Body = rest
+		return resp, nil
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	t.save(base, names, req, resp, body, ttl)
+	return resp, nil
+}
+
+// readBody reads rc so it can be stored, closing it. If rc holds more than
+// maxBody bytes, reading stops early and rest replays what was read
+// followed by the remainder of rc, which the caller must close.
+func (t *cachingTransport) readBody(rc io.ReadCloser) (body []byte, rest io.ReadCloser, err error) {
+	if t.maxBody <= 0 {
+		body, err = io.ReadAll(rc)
+		rc.Close()
+		return body, nil, err
+	}
+	body, err = io.ReadAll(io.LimitReader(rc, t.maxBody+1))
+	if err != nil {
+		rc.Close()
+		return nil, nil, err
+	}
+	if int64(len(body)) > t.maxBody {
+		return nil, struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), rc), rc}, nil
+	}
+	rc.Close()
+	return body, nil, nil
+}
+
+// load returns the cached response for key along with when it was stored
+// and until when it is fresh. Both times are zero for entries written
+// without them.
+func (t *cachingTransport) load(key string, req *http.Request) (resp *http.Response, storedAt, freshUntil time.Time) {
+	data, ok := t.cache.Get(key)
+	if !ok {
+		return nil, time.Time{}, time.Time{}
+	}
+	storedAt, freshUntil, dump := decodeEntry(data)
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+	if err != nil {
+		t.cache.Delete(key)
+		return nil, time.Time{}, time.Time{}
+	}
+	return resp, storedAt, freshUntil
+}
+
+// save stores resp under base for ttl, keeping entries with validators
+// around for revalidation after they go stale.
+func (t *cachingTransport) save(base string, names []string, req *http.Request, resp *http.Response, body []byte, ttl time.Duration) {
+	now := t.now()
+	keep := ttl
+	if hasValidators(resp.Header) {
+		keep += t.revalidate
+	}
+	if dump, err := dumpResponse(resp, body); err == nil {
+		t.store(base, names, req.Header, encodeEntry(now, now.Add(ttl), dump), keep)
+	}
+}
+
+// refresh serves cached after a 304, updating its headers from the
+// revalidation response and storing it again.
+func (t *cachingTransport) refresh(base string, req *http.Request, cached *http.Response, header http.Header) (*http.Response, error) {
+	body, rest, err := t.readBody(cached.Body)
+	if err != nil {
+		return nil, err
+	}
+	if rest != nil {
+		// Stored before the current limit; serve it without storing again.
+		cached.Body = rest
+		reportFreshness(req, Freshness{FromCache: true, Revalidated: true})
+		return cached, nil
+	}
+	for name, values := range header {
+		if !strings.HasPrefix(name, "Content-") {
+			cached.Header[name] = values
+		}
+	}
+	cached.Body = io.NopCloser(bytes.NewReader(body))
+
+	if ttl := t.ttl(cached); ttl > 0 {
+		if names, ok := varyNames(cached.Header); ok {
+			t.save(base, names, req, cached, body, ttl)
+		}
+	}
+	reportFreshness(req, Freshness{FromCache: true, Revalidated: true})
+	return cached, nil
+}
+
+func (t *cachingTransport) store(base string, names []string, header http.Header, data []byte, ttl time.Duration) {
+	key := variantKey(base, names, header)
+	now := t.now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.vary[base] = names
+	if t.keys[base] == nil {
+		t.keys[base] = make(map[string]time.Time)
+	}
+	t.keys[base][key] = now.Add(ttl)
+	t.cache.Set(key, data, ttl)
+
+	if len(t.keys) >= t.sweepAt {
+		t.sweep(now)
+		t.sweepAt = max(2*len(t.keys), minIndexSweep)
+	}
+}
+
+// sweep drops index entries that have expired from the cache. t.mu must be
+// held.
+func (t *cachingTransport) sweep(now time.Time) {
+	for base, keys := range t.keys {
+		for key, expires := range keys {
+			if !now.Before(expires) {
+				delete(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			delete(t.keys, base)
+			delete(t.vary, base)
+		}
+	}
+}
+
+// forget drops key, which the cache no longer holds, from the index.
+func (t *cachingTransport) forget(base, key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	keys, ok := t.keys[base]
+	if !ok {
+		return
+	}
+	delete(keys, key)
+	if len(keys) == 0 {
+		delete(t.keys, base)
+		delete(t.vary, base)
+	}
+}
+
+func (t *cachingTransport) purge(base string) {
+	t.mu.Lock()
+	keys := t.keys[base]
+	delete(t.keys, base)
+	delete(t.vary, base)
+	t.mu.Unlock()
+
+	t.cache.Delete(base)
+	for key := range keys {
+		t.cache.Delete(key)
+	}
+}
+
+// varyNames returns the canonical, sorted header names listed in Vary, and
+// false if the response varies on everything and cannot be cached.
+func varyNames(header http.Header) ([]string, bool) {
+	var names []string
+	for _, v := range header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" {
+				return nil, false
+			}
+			if name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names), true
+}
+
+// variantKey is the cache key for base as requested with header, given the
+// header names the response varies on.
+func variantKey(base string, names []string, header http.Header) string {
+	if len(names) == 0 {
+		return base
+	}
+	var b strings.Builder
+	b.WriteString(base)
+	for _, name := range names {
+		b.WriteString("\n")
+		b.WriteString(name)
+		b.WriteString(": ")
+		b.WriteString(strings.Join(header.Values(name), ","))
+	}
+	return b.String()
+}
+
+// ttl returns how long resp may be served from cache, or zero when it must
+// not be cached.
+func (t *cachingTransport) ttl(resp *http.Response) time.Duration {
+	if hasCacheDirective(resp.Header, "no-store") {
+		return 0
+	}
+	if t.negative[resp.StatusCode] {
+		return t.negativeTTL
+	}
+	if ttl, ok := t.headerTTL(resp); ok {
+		return ttl
+	}
+	if t.immutable && resp.StatusCode == http.StatusOK &&
+		hasCacheDirective(resp.Header, "immutable") && !hasCacheDirective(resp.Header, "no-cache") {
+		return immutableTTL
+	}
+	return cacheTTL(resp)
+}
+
+// headerTTL returns the TTL given by a successful resp's freshness header,
+// reporting whether it had a valid one.
+func (t *cachingTransport) headerTTL(resp *http.Response) (time.Duration, bool) {
+	if t.freshness == "" || resp.StatusCode != http.StatusOK {
+		return 0, false
+	}
+	values := resp.Header.Values(t.freshness)
+	if len(values) == 0 {
+		return 0, false
+	}
+	secs, err := strconv.Atoi(strings.TrimSpace(values[0]))
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+	return time.Duration(secs) * time.Second, true
+}
+
+// hasCacheDirective reports whether header's Cache-Control has the named
+// directive.
+func hasCacheDirective(header http.Header, name string) bool {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive, _, _ = strings.Cut(strings.TrimSpace(directive), "=")
+		if strings.EqualFold(directive, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheTTL returns how long a successful resp may be served from cache, or
+// zero when it must not be cached.
+func cacheTTL(resp *http.Response) time.Duration {
+	if resp.StatusCode != http.StatusOK {
+		return 0
+	}
+	var maxAge time.Duration
+	for _, directive := range strings.Split(resp.Header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store", "no-cache":
+			return 0
+		case "max-age":
+			secs, err := strconv.Atoi(value)
+			if err != nil {
+				return 0
+			}
+			maxAge = time.Duration(secs) * time.Second
+		}
+	}
+	return maxAge
+}
+
+func dumpResponse(resp *http.Response, body []byte) ([]byte, error) {
+	stored := *resp
+	stored.Body = io.NopCloser(bytes.NewReader(body))
+	stored.ContentLength = int64(len(body))
+	stored.TransferEncoding = nil
+	return httputil.DumpResponse(&stored, true)
+}
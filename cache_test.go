@@ -0,0 +1,591 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithCache(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl string
+		status       int
+		wantHits     int32
+	}{
+		{
+			name:         "max-age response served from cache",
+			cacheControl: "public, max-age=60",
+			status:       http.StatusOK,
+			wantHits:     1,
+		},
+		{
+			name:         "no-store not cached",
+			cacheControl: "no-store",
+			status:       http.StatusOK,
+			wantHits:     2,
+		},
+		{
+			name:         "no-cache not cached",
+			cacheControl: "no-cache, max-age=60",
+			status:       http.StatusOK,
+			wantHits:     2,
+		},
+		{
+			name:         "no cache headers not cached",
+			cacheControl: "",
+			status:       http.StatusOK,
+			wantHits:     2,
+		},
+		{
+			name:         "errors not cached",
+			cacheControl: "max-age=60",
+			status:       http.StatusNotFound,
+			wantHits:     2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				if tt.cacheControl != "" {
+					w.Header().Set("Cache-Control", tt.cacheControl)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`[{"id":1}]`))
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(WithCache(NewMemoryCache()))
+			for i := 0; i < 2; i++ {
+				body, err := FetchDataFrom(c, srv.URL)
+				if tt.status != http.StatusOK {
+					continue
+				}
+				if err != nil {
+					t.Fatalf("FetchDataFrom() request %d error = %v", i+1, err)
+				}
+				if string(body) != `[{"id":1}]` {
+					t.Errorf("FetchDataFrom() request %d = %q", i+1, body)
+				}
+			}
+
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("server hits = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestWithCache_MaxResponseBytes(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte(`[{"id":1},{"id":2}]`))
+	}))
+	defer srv.Close()
+
+	c := NewDefaultClient(WithCache(NewMemoryCache()), WithMaxResponseBytes(8))
+	for i := 0; i < 2; i++ {
+		if _, err := FetchDataFrom(c, srv.URL); !errors.Is(err, ErrResponseTooLarge) {
+			t.Fatalf("FetchDataFrom() request %d error = %v, want ErrResponseTooLarge", i+1, err)
+		}
+	}
+	if got := hits.Load(); got != 2 {
+		t.Errorf("server hits = %d, want 2", got)
+	}
+}
+
+func TestWithCache_OnlyGET(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+	}))
+	defer srv.Close()
+
+	c := NewDefaultClient(WithCache(NewMemoryCache()))
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, nil)
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if got := hits.Load(); got != 2 {
+		t.Errorf("server hits = %d, want 2", got)
+	}
+}
+
+func TestWithCache_Vary(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	defer srv.Close()
+
+	c := NewDefaultClient(WithCache(NewMemoryCache()))
+	get := func(lang string) string {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		req.Header.Set("Accept-Language", lang)
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	for _, lang := range []string{"en", "fr", "en", "fr"} {
+		if got := get(lang); got != lang {
+			t.Errorf("body = %q, want %q", got, lang)
+		}
+	}
+	if got := hits.Load(); got != 2 {
+		t.Errorf("server hits = %d, want 2", got)
+	}
+}
+
+func TestPurgeCache(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept")
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name  string
+		purge func(c *DefaultClient)
+	}{
+		{
+			name:  "purge url",
+			purge: func(c *DefaultClient) { c.PurgeCache(srv.URL + "/posts") },
+		},
+		{
+			name:  "purge all",
+			purge: func(c *DefaultClient) { c.PurgeAll() },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits.Store(0)
+			c := NewDefaultClient(WithCache(NewMemoryCache()))
+			get := func(accept string) {
+				t.Helper()
+				req, _ := http.NewRequest(http.MethodGet, srv.URL+"/posts", nil)
+				req.Header.Set("Accept", accept)
+				resp, err := c.Do(req)
+				if err != nil {
+					t.Fatalf("Do() error = %v", err)
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+
+			get("application/json")
+			get("text/plain")
+			get("application/json")
+			get("text/plain")
+			if got := hits.Load(); got != 2 {
+				t.Fatalf("server hits before purge = %d, want 2", got)
+			}
+
+			tt.purge(c)
+			get("application/json")
+			get("text/plain")
+			if got := hits.Load(); got != 4 {
+				t.Errorf("server hits after purge = %d, want 4", got)
+			}
+		})
+	}
+}
+
+func TestWithCache_IndexPruned(t *testing.T) {
+	var uncacheable atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=1")
+		if uncacheable.Load() {
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		w.Header().Set("Vary", "Accept")
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	get := func(t *testing.T, c *DefaultClient, path string) {
+		t.Helper()
+		if _, err := FetchDataFrom(c, srv.URL+path); err != nil {
+			t.Fatalf("FetchDataFrom(%s) error = %v", path, err)
+		}
+	}
+
+	t.Run("expired entries swept", func(t *testing.T) {
+		now := time.Unix(1_700_000_000, 0)
+		cache := NewMemoryCache()
+		cache.now = func() time.Time { return now }
+		c := NewDefaultClient(WithCache(cache))
+		c.cached.now = func() time.Time { return now }
+
+		for i := range 50 {
+			get(t, c, "/old/"+strconv.Itoa(i))
+		}
+		now = now.Add(2 * time.Second)
+		for i := range 50 {
+			get(t, c, "/new/"+strconv.Itoa(i))
+		}
+		if got := len(c.cached.keys); got != 50 {
+			t.Errorf("index holds %d URLs, want the 50 unexpired ones", got)
+		}
+	})
+
+	t.Run("evicted entry forgotten on miss", func(t *testing.T) {
+		c := NewDefaultClient(WithCache(NewLRUCache(1, 0)))
+		get(t, c, "/a")
+		get(t, c, "/b") // evicts /a
+		uncacheable.Store(true)
+		defer uncacheable.Store(false)
+		get(t, c, "/a")
+		if _, ok := c.cached.keys[srv.URL+"/a"]; ok || len(c.cached.keys) != 1 {
+			t.Errorf("index holds %d URLs (/a present: %v), want only /b", len(c.cached.keys), ok)
+		}
+	})
+}
+
+func TestWithCacheKeyFunc(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	// Ignore the volatile ts parameter; never cache requests with nocache set.
+	keyFunc := func(req *http.Request) string {
+		q := req.URL.Query()
+		if q.Has("nocache") {
+			return ""
+		}
+		q.Del("ts")
+		u := *req.URL
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+
+	tests := []struct {
+		name     string
+		paths    []string
+		wantHits int32
+	}{
+		{
+			name:     "differing timestamps share an entry",
+			paths:    []string{"/posts?page=1&ts=100", "/posts?page=1&ts=200"},
+			wantHits: 1,
+		},
+		{
+			name:     "other params still distinguish entries",
+			paths:    []string{"/posts?page=1&ts=100", "/posts?page=2&ts=100"},
+			wantHits: 2,
+		},
+		{
+			name:     "empty key bypasses cache",
+			paths:    []string{"/posts?nocache=1", "/posts?nocache=1"},
+			wantHits: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits.Store(0)
+			c := NewDefaultClient(WithCache(NewMemoryCache()), WithCacheKeyFunc(keyFunc))
+			for _, path := range tt.paths {
+				if _, err := FetchDataFrom(c, srv.URL+path); err != nil {
+					t.Fatalf("FetchDataFrom(%s) error = %v", path, err)
+				}
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("server hits = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestWithNegativeCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		opts     []Option
+		advance  time.Duration // between the two requests
+		wantHits int32
+	}{
+		{
+			name:     "404 served from cache within TTL",
+			status:   http.StatusNotFound,
+			opts:     []Option{WithNegativeCache(5 * time.Second)},
+			advance:  time.Second,
+			wantHits: 1,
+		},
+		{
+			name:     "404 refetched after TTL",
+			status:   http.StatusNotFound,
+			opts:     []Option{WithNegativeCache(5 * time.Second)},
+			advance:  6 * time.Second,
+			wantHits: 2,
+		},
+		{
+			name:     "unlisted code not cached",
+			status:   http.StatusGone,
+			opts:     []Option{WithNegativeCache(5*time.Second, http.StatusNotFound)},
+			advance:  time.Second,
+			wantHits: 2,
+		},
+		{
+			name:     "disabled",
+			status:   http.StatusNotFound,
+			advance:  time.Second,
+			wantHits: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				w.Header().Set("Cache-Control", "max-age=3600")
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			now := time.Now()
+			cache := NewMemoryCache()
+			cache.now = func() time.Time { return now }
+			c := NewDefaultClient(append(tt.opts, WithCache(cache))...)
+			c.cached.now = func() time.Time { return now }
+
+			for i := range 2 {
+				if i == 1 {
+					now = now.Add(tt.advance)
+				}
+				_, err := FetchDataFrom(c, srv.URL)
+				var herr *HTTPError
+				if !errors.As(err, &herr) || herr.StatusCode != tt.status {
+					t.Fatalf("request %d: err = %v, want an *HTTPError with status %d", i+1, err, tt.status)
+				}
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("server hits = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+
+	t.Run("resource appearing after TTL", func(t *testing.T) {
+		var found atomic.Bool
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !found.Load() {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte("here"))
+		}))
+		defer srv.Close()
+
+		now := time.Now()
+		cache := NewMemoryCache()
+		cache.now = func() time.Time { return now }
+		c := NewDefaultClient(WithCache(cache), WithNegativeCache(time.Second))
+		c.cached.now = func() time.Time { return now }
+
+		if _, err := FetchDataFrom(c, srv.URL); err == nil {
+			t.Fatal("expected a 404 error")
+		}
+		found.Store(true)
+		now = now.Add(2 * time.Second)
+		if body, err := FetchDataFrom(c, srv.URL); err != nil || string(body) != "here" {
+			t.Errorf("FetchDataFrom = %q, %v; want the new resource", body, err)
+		}
+	})
+}
+
+func TestWithImmutableCache(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl string
+		opts         []Option
+		wantHits     int32
+	}{
+		{
+			name:         "immutable served from cache",
+			cacheControl: "max-age=1, immutable",
+			opts:         []Option{WithImmutableCache()},
+			wantHits:     1,
+		},
+		{
+			name:         "immutable without max-age",
+			cacheControl: "public, immutable",
+			opts:         []Option{WithImmutableCache()},
+			wantHits:     1,
+		},
+		{
+			name:         "disabled revalidates",
+			cacheControl: "max-age=1, immutable",
+			wantHits:     2,
+		},
+		{
+			name:         "not immutable",
+			cacheControl: "max-age=1",
+			opts:         []Option{WithImmutableCache()},
+			wantHits:     2,
+		},
+		{
+			name:         "no-store wins",
+			cacheControl: "no-store, immutable",
+			opts:         []Option{WithImmutableCache()},
+			wantHits:     2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				w.Header().Set("Cache-Control", tt.cacheControl)
+				w.Header().Set("ETag", `"abc123"`)
+				if r.Header.Get("If-None-Match") == `"abc123"` {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Write([]byte("asset"))
+			}))
+			defer srv.Close()
+
+			now := time.Now()
+			cache := NewMemoryCache()
+			cache.now = func() time.Time { return now }
+			c := NewDefaultClient(append(tt.opts, WithCache(cache))...)
+			c.cached.now = func() time.Time { return now }
+
+			for i := range 2 {
+				if i == 1 {
+					now = now.Add(24 * time.Hour)
+				}
+				body, err := FetchDataFrom(c, srv.URL)
+				if err != nil || string(body) != "asset" {
+					t.Fatalf("request %d: FetchDataFrom = %q, %v", i+1, body, err)
+				}
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("server hits = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestWithFreshnessHeader(t *testing.T) {
+	tests := []struct {
+		name         string
+		freshness    string // X-Fresh-For value, "" for none
+		cacheControl string
+		advance      time.Duration
+		wantHits     int32
+	}{
+		{
+			name:         "header extends TTL",
+			freshness:    "120",
+			cacheControl: "max-age=10",
+			advance:      time.Minute,
+			wantHits:     1,
+		},
+		{
+			name:         "header shortens TTL",
+			freshness:    "10",
+			cacheControl: "max-age=3600",
+			advance:      time.Minute,
+			wantHits:     2,
+		},
+		{
+			name:         "header overrides no-cache",
+			freshness:    "120",
+			cacheControl: "no-cache",
+			advance:      time.Minute,
+			wantHits:     1,
+		},
+		{
+			name:         "malformed falls back",
+			freshness:    "soon",
+			cacheControl: "max-age=3600",
+			advance:      time.Minute,
+			wantHits:     1,
+		},
+		{
+			name:         "malformed falls back to expiry",
+			freshness:    "-5",
+			cacheControl: "max-age=10",
+			advance:      time.Minute,
+			wantHits:     2,
+		},
+		{
+			name:         "absent falls back",
+			cacheControl: "max-age=3600",
+			advance:      time.Minute,
+			wantHits:     1,
+		},
+		{
+			name:         "no-store honored",
+			freshness:    "120",
+			cacheControl: "no-store",
+			advance:      time.Second,
+			wantHits:     2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				w.Header().Set("Cache-Control", tt.cacheControl)
+				if tt.freshness != "" {
+					w.Header().Set("X-Fresh-For", tt.freshness)
+				}
+				w.Write([]byte("data"))
+			}))
+			defer srv.Close()
+
+			now := time.Now()
+			cache := NewMemoryCache()
+			cache.now = func() time.Time { return now }
+			c := NewDefaultClient(WithCache(cache), WithFreshnessHeader("X-Fresh-For"))
+			c.cached.now = func() time.Time { return now }
+
+			for i := range 2 {
+				if i == 1 {
+					now = now.Add(tt.advance)
+				}
+				if _, err := FetchDataFrom(c, srv.URL); err != nil {
+					t.Fatalf("FetchDataFrom() request %d error = %v", i+1, err)
+				}
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("server hits = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RecordMode selects whether a cassette records or replays responses.
+type RecordMode int
+
+const (
+	// ModeRecord sends requests to the network and writes every
+	// interaction to the cassette file, replacing what it held before.
+	ModeRecord RecordMode = iota
+	// ModeReplay serves responses from the cassette file without touching
+	// the network.
+	ModeReplay
+)
+
+// ErrCassetteMiss is returned in ModeReplay when no recorded interaction
+// matches a request.
+var ErrCassetteMiss = errors.New("no recorded interaction matches the request")
+
+// WithCassette records responses to the JSON file at path, or replays them
+// from it, for golden-file tests. Requests are matched by method, URL and
+// body; when the same request was recorded more than once the recordings are
+// replayed in order, the last one repeating. Headers listed by
+// WithRedactedHeaders are recorded as Redacted.
+func WithCassette(path string, mode RecordMode) Option {
+	return func(c *DefaultClient) {
+		c.cassette = &cassetteTransport{path: path, mode: mode}
+		if mode == ModeReplay {
+			c.cassette.load()
+		}
+	}
+}
+
+type interaction struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	Body         []byte      `json:"body,omitempty"`
+	Status       int         `json:"status"`
+	Header       http.Header `json:"header"`
+	ResponseBody []byte      `json:"response_body,omitempty"`
+}
+
+func (i *interaction) matches(req *http.Request, body []byte) bool {
+	return i.Method == req.Method && i.URL == req.URL.String() && bytes.Equal(i.Body, body)
+}
+
+type cassetteTransport struct {
+	next   http.RoundTripper
+	path   string
+	mode   RecordMode
+	redact func(http.Header) http.Header
+
+	mu           sync.Mutex
+	interactions []interaction
+	played       map[int]bool
+	loadErr      error
+}
+
+func (t *cassetteTransport) load() {
+	data, err := os.ReadFile(t.path)
+	if err == nil {
+		err = json.Unmarshal(data, &t.interactions)
+	}
+	if err != nil {
+		t.loadErr = fmt.Errorf("failed to load cassette: %w", err)
+	}
+	t.played = make(map[int]bool)
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if t.mode == ModeReplay {
+		return t.replay(req, body)
+	}
+	return t.record(req, body)
+}
+
+func (t *cassetteTransport) replay(req *http.Request, body []byte) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.loadErr != nil {
+		return nil, t.loadErr
+	}
+	last := -1
+	for i := range t.interactions {
+		if !t.interactions[i].matches(req, body) {
+			continue
+		}
+		last = i
+		if !t.played[i] {
+			break
+		}
+	}
+	if last < 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrCassetteMiss, req.Method, req.URL)
+	}
+	t.played[last] = true
+	in := t.interactions[last]
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+		StatusCode:    in.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        in.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(in.ResponseBody)),
+		ContentLength: int64(len(in.ResponseBody)),
+		Request:       req,
+	}, nil
+}
+
+func (t *cassetteTransport) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.interactions = append(t.interactions, interaction{
+		Method:       req.Method,
+		URL:          req.URL.String(),
+		Body:         body,
+		Status:       resp.StatusCode,
+		Header:       t.redact(resp.Header),
+		ResponseBody: respBody,
+	})
+	if err := t.save(); err != nil {
+		return nil, fmt.Errorf("failed to save cassette: %w", err)
+	}
+	return resp, nil
+}
+
+// save writes the cassette to a temporary file and renames it so a crash
+// never leaves a partial cassette behind.
+func (t *cassetteTransport) save() error {
+	data, err := json.MarshalIndent(t.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(t.path), ".cassette-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), t.path)
+}
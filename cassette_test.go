@@ -0,0 +1,85 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithCassette(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Echo", r.Method)
+		io.WriteString(w, r.Method+" "+r.URL.Path+" "+string(body))
+	}))
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	requests := []struct {
+		method, path, body string
+	}{
+		{http.MethodGet, "/posts", ""},
+		{http.MethodPost, "/posts", `{"title":"a"}`},
+		{http.MethodPost, "/posts", `{"title":"b"}`},
+	}
+	send := func(t *testing.T, c *DefaultClient, method, url, body string) (*http.Response, string, error) {
+		t.Helper()
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.Do(req)
+		if err != nil {
+			return nil, "", err
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, string(b), nil
+	}
+
+	recorder := NewDefaultClient(WithCassette(path, ModeRecord))
+	recorded := make([]string, len(requests))
+	for i, r := range requests {
+		_, body, err := send(t, recorder, r.method, srv.URL+r.path, r.body)
+		if err != nil {
+			t.Fatalf("record %s %s: %v", r.method, r.path, err)
+		}
+		recorded[i] = body
+	}
+	url := srv.URL
+	srv.Close()
+
+	replayer := NewDefaultClient(WithCassette(path, ModeReplay))
+	for i := len(requests) - 1; i >= 0; i-- {
+		r := requests[i]
+		resp, body, err := send(t, replayer, r.method, url+r.path, r.body)
+		if err != nil {
+			t.Fatalf("replay %s %s: %v", r.method, r.path, err)
+		}
+		if body != recorded[i] {
+			t.Errorf("replayed body = %q, want %q", body, recorded[i])
+		}
+		if got := resp.Header.Get("X-Echo"); got != r.method {
+			t.Errorf("X-Echo = %q, want %q", got, r.method)
+		}
+	}
+
+	if _, _, err := send(t, replayer, http.MethodPost, url+"/posts", `{"title":"c"}`); !errors.Is(err, ErrCassetteMiss) {
+		t.Errorf("unrecorded request err = %v, want ErrCassetteMiss", err)
+	}
+}
+
+func TestWithCassette_MissingFile(t *testing.T) {
+	c := NewDefaultClient(WithCassette(filepath.Join(t.TempDir(), "missing.json"), ModeReplay))
+	resp, err := c.Get("http://example.invalid/")
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected an error for a missing cassette")
+	}
+}
@@ -0,0 +1,26 @@
+package client
+
+import "net/http"
+
+// WithChunkedUpload sends request bodies with Transfer-Encoding: chunked
+// instead of a Content-Length, streaming them straight from the caller's
+// reader so large uploads from pipes or other unknown-length sources are
+// never held in memory. Streamed bodies cannot be replayed: requests with a
+// body are not retried, and 307/308 redirects are returned instead of
+// followed unless WithMaxRedirectBodyReplay is set. WithRequestTap still
+// buffers the bodies it copies.
+func WithChunkedUpload() Option {
+	return func(c *DefaultClient) {
+		c.chunkedUpload = true
+	}
+}
+
+func (c *DefaultClient) chunkRequest(req *http.Request) *http.Request {
+	if !c.chunkedUpload || req.Body == nil || req.Body == http.NoBody {
+		return req
+	}
+	req = req.Clone(req.Context())
+	req.ContentLength = -1
+	req.GetBody = nil
+	return req
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWithChunkedUpload(t *testing.T) {
+	const payload = "chunk;chunk;chunk;"
+
+	tests := []struct {
+		name string
+		body func() io.Reader
+	}{
+		{
+			name: "pipe",
+			body: func() io.Reader {
+				pr, pw := io.Pipe()
+				go func() {
+					for i := 0; i < 3; i++ {
+						io.WriteString(pw, "chunk;")
+					}
+					pw.Close()
+				}()
+				return pr
+			},
+		},
+		{
+			name: "known length reader",
+			body: func() io.Reader { return strings.NewReader(payload) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			var gotEncoding []string
+			var gotBody string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				gotEncoding = r.TransferEncoding
+				body, _ := io.ReadAll(r.Body)
+				gotBody = string(body)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(WithChunkedUpload(), WithRetry(3, fastBackoff))
+			req, _ := http.NewRequest(http.MethodPut, srv.URL, tt.body())
+			resp, err := c.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if len(gotEncoding) != 1 || gotEncoding[0] != "chunked" {
+				t.Errorf("TransferEncoding = %v, want [chunked]", gotEncoding)
+			}
+			if gotBody != payload {
+				t.Errorf("body = %q, want %q", gotBody, payload)
+			}
+			if got := hits.Load(); got != 1 {
+				t.Errorf("server hits = %d, want 1: streamed bodies must not be retried", got)
+			}
+		})
+	}
+}
@@ -1,9 +1,20 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
+	"math/rand"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
 )
 
 const (
@@ -14,35 +25,453 @@ type HTTPClient interface {
 	Get(url string) (*http.Response, error)
 }
 
+// Doer is implemented by clients that can send arbitrary requests.
+// DefaultClient implements it.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
 type DefaultClient struct {
-	client *http.Client
+	client    *http.Client
+	endpoint  string
+	optionErr error
+	transport *http.Transport
+	dialer    *net.Dialer
+	dial      dialFunc
+	dnsCache  *dnsCache
+
+	maxResponseBytes  int64
+	requestIDKey      any
+	defaultQuery      url.Values
+	chunkedUpload     bool
+	gzipThreshold     int
+	maxRedirectReplay int64
+	pooledBuffers     bool
+
+	connReuseCallback func(reused bool)
+	errorMapper       func(error) error
+	errorDecoder      func(status int, body []byte) error
+	signatureVerifier func(body []byte, header http.Header) error
+	schema            *jsonschema.Schema
+	responseTap       io.Writer
+	bodyWrappers      []func(io.Reader) io.Reader
+	maxLineBytes      int
+	requestTap        io.Writer
+	requestTapRedact  func([]byte) []byte
+	requestEditors    []RequestEditorFn
+	signingKeyID      string
+	signingSecret     []byte
+	responseEditors   []ResponseEditorFn
+	transformers      [numStages][]Transformer
+	coalesce          *coalesceGroup
+
+	maxAttempts       int
+	backoff           Backoff
+	jitter            *jitter
+	retryStatus       map[int]bool
+	retryClassifier   func(resp *http.Response) bool
+	retryErrors       []error
+	retryHeader       string
+	budget            *retryBudget
+	retryDecodeErrors bool
+	maxRetryAfter     time.Duration
+	retryEmptyBody    bool
+
+	connectAttempts int
+	connectBackoff  Backoff
+
+	timeout          time.Duration
+	maxRedirectTime  time.Duration
+	perTryTimeout    time.Duration
+	bodyReadTimeout  time.Duration
+	stallTimeout     time.Duration
+	deadlineHeader   string
+	firstByteTimeout time.Duration
+
+	cache              Cache
+	cacheKey           func(req *http.Request) string
+	negativeTTL        time.Duration
+	negativeCodes      map[int]bool
+	immutableCache     bool
+	freshnessHeader    string
+	revalidateWindow   time.Duration
+	cached             *cachingTransport
+	clock              Clock
+	metrics            Metrics
+	latencyCallback    func(url string, status int, d time.Duration)
+	afterRequest       func(ctx context.Context, info RequestInfo)
+	pool               *pool
+	limiter            *limiter
+	hostIdle           map[string]time.Duration
+	connLifetime       time.Duration
+	hedgeDelay         time.Duration
+	cassette           *cassetteTransport
+	validateUTF8       bool
+	retainedHeaders    map[string]bool
+	redactedHeaders    map[string]bool
+	compressionMetric  bool
+	resilientStreaming bool
+	noRedirects        bool
+	rawBody            bool
+	acceptEncoding     string
+	breaker            *Breaker
+	balancer           *balancer
+	sampleRate         float64
+	sampleBytes        int
+	sampler            *sampler
+	logger             *slog.Logger
+	leaks              *leakTransport
+	name               string
+	fallback           string
+	pageParam          string
+}
+
+func NewDefaultClient(opts ...Option) *DefaultClient {
+	c := &DefaultClient{
+		transport: http.DefaultTransport.(*http.Transport).Clone(),
+		dialer: &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		},
+		clock:            systemClock{},
+		maxAttempts:      1,
+		backoff:          DefaultBackoff,
+		jitter:           newJitter(rand.NewSource(time.Now().UnixNano())),
+		sampler:          newSampler(rand.NewSource(time.Now().UnixNano())),
+		retryStatus:      retryableStatus,
+		revalidateWindow: defaultRevalidateWindow,
+	}
+	c.dial = c.dialer.DialContext
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.name != "" && c.metrics != nil {
+		c.metrics = namedMetrics{Metrics: c.metrics, name: c.name}
+	}
+	c.transport.DialContext = c.dial
+	c.client = &http.Client{
+		Transport:     c.roundTripper(),
+		CheckRedirect: c.checkRedirect,
+	}
+	return c
 }
 
-func NewDefaultClient() *DefaultClient {
-	return &DefaultClient{
-		client: &http.Client{},
+// roundTripper wraps the transport with the configured middleware.
+func (c *DefaultClient) roundTripper() http.RoundTripper {
+	var rt http.RoundTripper = c.transport
+	if c.cassette != nil {
+		c.cassette.next = rt
+		c.cassette.redact = c.RedactHeaders
+		rt = c.cassette
+	}
+	if c.cache != nil {
+		c.cached = newCachingTransport(rt, c.cache, c.cacheKey)
+		c.cached.negativeTTL, c.cached.negative = c.negativeTTL, c.negativeCodes
+		c.cached.immutable = c.immutableCache
+		c.cached.freshness = c.freshnessHeader
+		c.cached.revalidate = c.revalidateWindow
+		c.cached.maxBody = c.maxResponseBytes
+		rt = c.cached
+	}
+	if c.leaks != nil {
+		c.leaks.next = rt
+		rt = c.leaks
 	}
+	return rt
 }
 
 func (c *DefaultClient) Get(url string) (*http.Response, error) {
-	return c.client.Get(url)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+func (c *DefaultClient) Do(req *http.Request) (*http.Response, error) {
+	return c.observe(req, c.do)
+}
+
+func (c *DefaultClient) do(req *http.Request) (*http.Response, error) {
+	// Record every call, including those that fail before being sent.
+	// Steps that fail return a nil request, so keep the last good one.
+	var (
+		sent  = req
+		got   *http.Response
+		start = time.Now()
+	)
+	defer func() {
+		if req != nil {
+			sent = req
+		}
+		c.recordRequest(sent, got, start)
+	}()
+	if c.optionErr != nil {
+		return nil, c.optionErr
+	}
+
+	req = c.applyDefaultQuery(req)
+	req = c.injectRequestID(req)
+	req, err := c.editRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	req = c.chunkRequest(req)
+	req, err = c.tapRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	req, err = c.gzipRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	req, err = c.signRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	req = c.traceRequest(req)
+	req = c.trackIdle(req)
+	req, compressed := c.requestCompression(req)
+	req, cancel := c.withTimeout(req)
+	leave, err := c.limiter.acquire(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	start = time.Now()
+	resp, err := c.doWithRetry(req)
+	got = resp
+	if err == nil {
+		err = c.redirectError(resp)
+	}
+	if err == nil {
+		err = c.editResponse(req, resp)
+	}
+	if err != nil {
+		cancel()
+		leave()
+		return nil, err
+	}
+	if c.resumes(req, resp) {
+		resp.Body = c.resumingBody(req, resp)
+	}
+	resp.Body = &cancelBody{rc: resp.Body, cancel: cancel}
+	if c.limiter != nil {
+		resp.Body = &releaseBody{rc: resp.Body, release: leave}
+	}
+	if c.bodyReadTimeout > 0 {
+		resp.Body = newDeadlineBody(resp.Body, c.bodyReadTimeout)
+	}
+	if c.stallTimeout > 0 {
+		resp.Body = newStallBody(resp.Body, c.stallTimeout)
+	}
+	if err := c.transform(req, resp, compressed); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	c.sampleBody(req, resp)
+	return resp, nil
 }
 
 func FetchData(client HTTPClient) ([]byte, error) {
-	resp, err := client.Get(Endpoint)
+	return withFallback(context.Background(), client, func(url string) ([]byte, error) {
+		return FetchDataFrom(client, url)
+	})
+}
+
+// WithDefaultEndpoint sets the URL FetchData and FetchPosts use instead of
+// Endpoint.
+func WithDefaultEndpoint(url string) Option {
+	return func(c *DefaultClient) {
+		c.endpoint = url
+	}
+}
+
+// endpointer is implemented by clients with their own default endpoint.
+type endpointer interface {
+	defaultEndpoint() string
+}
+
+func (c *DefaultClient) defaultEndpoint() string {
+	return c.endpoint
+}
+
+// WithFallbackEndpoint makes FetchData and FetchPosts try url once more
+// when the default endpoint fails, after its own retries. If both fail, the
+// error names both endpoints.
+func WithFallbackEndpoint(url string) Option {
+	return func(c *DefaultClient) {
+		c.fallback = url
+	}
+}
+
+// fallbacker is implemented by clients with a fallback endpoint.
+type fallbacker interface {
+	fallbackEndpoint() string
+}
+
+func (c *DefaultClient) fallbackEndpoint() string {
+	return c.fallback
+}
+
+// withFallback runs fetch against client's default endpoint, then against
+// its fallback endpoint if that fails.
+func withFallback[T any](ctx context.Context, client HTTPClient, fetch func(url string) (T, error)) (T, error) {
+	primary := defaultEndpoint(client)
+	v, err := fetch(primary)
+	fb, ok := client.(fallbacker)
+	if err == nil || !ok || fb.fallbackEndpoint() == "" || ctx.Err() != nil {
+		return v, err
+	}
+	fallback := fb.fallbackEndpoint()
+	v, ferr := fetch(fallback)
+	if ferr != nil {
+		return v, fmt.Errorf("primary endpoint %s: %w; fallback endpoint %s: %w", primary, err, fallback, ferr)
+	}
+	return v, nil
+}
+
+// defaultEndpoint returns client's default endpoint, falling back to
+// Endpoint.
+func defaultEndpoint(client HTTPClient) string {
+	if e, ok := client.(endpointer); ok {
+		if url := e.defaultEndpoint(); url != "" {
+			return url
+		}
+	}
+	return Endpoint
+}
+
+func FetchDataFrom(client HTTPClient, url string) ([]byte, error) {
+	return FetchDataContext(context.Background(), client, url)
+}
+
+// FetchDataContext fetches url, cancelling the request and releasing its
+// connection as soon as ctx is done.
+func FetchDataContext(ctx context.Context, client HTTPClient, url string) ([]byte, error) {
+	resp, err := fetch(ctx, client, url)
+	if err != nil {
+		return nil, mapError(client, err)
+	}
+	return resp.Body, nil
+}
+
+func fetch(ctx context.Context, client HTTPClient, url string) (*Response, error) {
+	if co, ok := client.(coalescer); ok {
+		return co.coalesceFetch(ctx, url, func(ctx context.Context) (*Response, error) {
+			return fetchOnce(ctx, client, url)
+		})
+	}
+	return fetchOnce(ctx, client, url)
+}
+
+func fetchOnce(ctx context.Context, client HTTPClient, url string) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	return execute(client, req, func(status int) bool { return status == http.StatusOK })
+}
+
+// execute sends req and reads the full response, returning an *HTTPError
+// when ok rejects the status code.
+func execute(client HTTPClient, req *http.Request, ok func(status int) bool) (*Response, error) {
+	ctx := req.Context()
+	addrs := &remoteAddrs{tries: make(map[*http.Response]string)}
+	req = req.WithContext(httptrace.WithClientTrace(context.WithValue(ctx, remoteAddrsKey{}, addrs), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			addrs.mu.Lock()
+			defer addrs.mu.Unlock()
+			addrs.last = info.Conn.RemoteAddr().String()
+		},
+	}))
+	resp, err := send(client, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch data: %w", err)
 	}
 	defer resp.Body.Close()
+	// Clients that ignore the request context still get their body closed
+	// on cancellation, so a blocked read returns and the connection is freed.
+	stop := context.AfterFunc(ctx, func() { resp.Body.Close() })
+	defer stop()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	if !ok(resp.StatusCode) {
+		return nil, statusError(client, resp)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := readBody(client, resp.Body)
 	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	return body, nil
+	r := newResponse(resp, req.URL.String(), body)
+	r.RemoteAddr = addrs.of(resp)
+	if hr, ok := client.(headerRetainer); ok {
+		r.Header = hr.retainHeaders(r.Header)
+	}
+	return r, nil
+}
+
+type remoteAddrsKey struct{}
+
+// remoteAddrs collects the server addresses a request was sent to. Clients
+// that report each try's response with trackRemoteAddr are looked up by
+// response, so a hedged or retried request gets the address of the try
+// that was returned; for other clients the last connection made is used.
+type remoteAddrs struct {
+	mu    sync.Mutex
+	tries map[*http.Response]string
+	last  string
+}
+
+func (a *remoteAddrs) of(resp *http.Response) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.tries) == 0 {
+		return a.last
+	}
+	return a.tries[resp]
+}
+
+// trackRemoteAddr records the address of the connection that serves a
+// single try of req, for execute to report. The returned function records
+// the try's response.
+func trackRemoteAddr(req *http.Request) (*http.Request, func(*http.Response)) {
+	addrs, ok := req.Context().Value(remoteAddrsKey{}).(*remoteAddrs)
+	if !ok {
+		return req, func(*http.Response) {}
+	}
+	var addr atomic.Pointer[string]
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			a := info.Conn.RemoteAddr().String()
+			addr.Store(&a)
+		},
+	}))
+	return req, func(resp *http.Response) {
+		var a string
+		if p := addr.Load(); p != nil {
+			a = *p
+		}
+		addrs.mu.Lock()
+		defer addrs.mu.Unlock()
+		addrs.tries[resp] = a
+	}
+}
+
+// send issues req through client. Clients that only implement Get can still
+// serve GET requests, but any request headers are dropped.
+func send(client HTTPClient, req *http.Request) (*http.Response, error) {
+	if d, ok := client.(Doer); ok {
+		return d.Do(req)
+	}
+	if req.Method != http.MethodGet {
+		return nil, fmt.Errorf("client does not support %s requests", req.Method)
+	}
+	return client.Get(req.URL.String())
 }
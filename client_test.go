@@ -1,13 +1,18 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/therewardstore/httpmatter"
+	"go.uber.org/goleak"
 )
 
 type mockHTTPClient struct {
@@ -382,8 +387,214 @@ func TestNewDefaultClient(t *testing.T) {
 	}
 }
 
+type blockingDoer struct {
+	sawCancelled chan error
+}
+
+func (b *blockingDoer) Get(url string) (*http.Response, error) {
+	return nil, errors.New("Get should not be used")
+}
+
+func (b *blockingDoer) Do(req *http.Request) (*http.Response, error) {
+	<-req.Context().Done()
+	b.sawCancelled <- req.Context().Err()
+	return nil, req.Context().Err()
+}
+
+func TestFetchDataContext_CancelBeforeResponse(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	doer := &blockingDoer{sawCancelled: make(chan error, 1)}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, err := FetchDataContext(ctx, doer, Endpoint)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("FetchDataContext() error = %v, want context.Canceled", err)
+	}
+	if got := <-doer.sawCancelled; !errors.Is(got, context.Canceled) {
+		t.Errorf("underlying request context error = %v, want context.Canceled", got)
+	}
+}
+
+func TestFetchDataContext_CancelDuringBody(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":1},`))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c := NewDefaultClient()
+	defer c.transport.CloseIdleConnections()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, err := FetchDataContext(ctx, c, srv.URL)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("FetchDataContext() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestFetchDataContext_GetOnlyClientReleasesBody(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[`))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	// mockHTTPClient only implements Get, so the request context never
+	// reaches the transport.
+	getOnly := &mockHTTPClient{doFunc: http.DefaultClient.Get}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := FetchDataContext(ctx, getOnly, srv.URL)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("FetchDataContext() error = %v, want context.DeadlineExceeded", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("FetchDataContext() did not return after the context expired")
+	}
+}
+
 type errorReader struct{}
 
 func (e *errorReader) Read(p []byte) (n int, err error) {
 	return 0, fmt.Errorf("read error")
 }
+
+func TestWithDefaultEndpoint(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Write([]byte(`[{"id":7}]`))
+	}))
+	defer srv.Close()
+
+	c := NewDefaultClient(WithDefaultEndpoint(srv.URL + "/v2/posts"))
+	body, err := FetchData(c)
+	if err != nil {
+		t.Fatalf("FetchData() error = %v", err)
+	}
+	if string(body) != `[{"id":7}]` || gotPath != "/v2/posts" {
+		t.Errorf("FetchData got %q from %q, want the overridden endpoint", body, gotPath)
+	}
+
+	gotPath = ""
+	posts, err := FetchPosts(context.Background(), c)
+	if err != nil {
+		t.Fatalf("FetchPosts() error = %v", err)
+	}
+	if len(posts) != 1 || posts[0].ID != 7 || gotPath != "/v2/posts" {
+		t.Errorf("FetchPosts got %+v from %q, want the overridden endpoint", posts, gotPath)
+	}
+
+	if got := defaultEndpoint(NewDefaultClient()); got != Endpoint {
+		t.Errorf("default endpoint = %q, want %q", got, Endpoint)
+	}
+}
+
+func TestWithFallbackEndpoint(t *testing.T) {
+	var primaryCalls, fallbackCalls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/primary":
+			primaryCalls++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/fallback":
+			fallbackCalls++
+			w.Write([]byte(`[{"id":9}]`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		fallback     string
+		wantBody     string
+		wantPrimary  int
+		wantFallback int
+	}{
+		{
+			name:         "fallback succeeds",
+			fallback:     "/fallback",
+			wantBody:     `[{"id":9}]`,
+			wantPrimary:  2,
+			wantFallback: 1,
+		},
+		{
+			name:        "both fail",
+			fallback:    "/broken",
+			wantPrimary: 2,
+		},
+		{
+			name:        "no fallback",
+			wantPrimary: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primaryCalls, fallbackCalls = 0, 0
+			opts := []Option{WithDefaultEndpoint(srv.URL + "/primary"), WithRetry(2, fastBackoff)}
+			if tt.fallback != "" {
+				opts = append(opts, WithFallbackEndpoint(srv.URL+tt.fallback))
+			}
+			body, err := FetchData(NewDefaultClient(opts...))
+
+			switch {
+			case tt.wantBody != "":
+				if err != nil || string(body) != tt.wantBody {
+					t.Errorf("FetchData = %q, %v; want %q", body, err, tt.wantBody)
+				}
+			case err == nil:
+				t.Error("expected an error")
+			case tt.fallback != "":
+				for _, path := range []string{"/primary", tt.fallback} {
+					if !strings.Contains(err.Error(), srv.URL+path) {
+						t.Errorf("err = %q, want it to mention %s", err, path)
+					}
+				}
+				var herr *HTTPError
+				if !errors.As(err, &herr) {
+					t.Errorf("err = %v, want it to wrap an *HTTPError", err)
+				}
+			}
+			if primaryCalls != tt.wantPrimary || fallbackCalls != tt.wantFallback {
+				t.Errorf("calls = %d primary, %d fallback; want %d, %d", primaryCalls, fallbackCalls, tt.wantPrimary, tt.wantFallback)
+			}
+		})
+	}
+
+	t.Run("FetchPosts", func(t *testing.T) {
+		c := NewDefaultClient(WithDefaultEndpoint(srv.URL+"/primary"), WithFallbackEndpoint(srv.URL+"/fallback"))
+		posts, err := FetchPosts(context.Background(), c)
+		if err != nil || len(posts) != 1 || posts[0].ID != 9 {
+			t.Errorf("FetchPosts = %+v, %v; want the fallback's posts", posts, err)
+		}
+	})
+}
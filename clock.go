@@ -0,0 +1,21 @@
+package client
+
+import "time"
+
+// Clock supplies the current time to time-dependent features such as
+// request signing. Tests can pin it to get deterministic output.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// WithClock makes the client read the current time from clock instead of
+// the system clock.
+func WithClock(clock Clock) Option {
+	return func(c *DefaultClient) {
+		c.clock = clock
+	}
+}
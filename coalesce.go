@@ -0,0 +1,140 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// WithCoalesceWindow makes FetchData and the other full-body fetch helpers
+// share one network result between calls to the same URL: calls made while
+// a fetch is in flight, or within d after it succeeded, get a copy of its
+// response instead of making their own request. Failures are not kept, so
+// the next call after one fetches again.
+func WithCoalesceWindow(d time.Duration) Option {
+	return func(c *DefaultClient) {
+		if d > 0 {
+			c.coalesce = &coalesceGroup{window: d, flights: make(map[string]*flight)}
+		} else {
+			c.coalesce = nil
+		}
+	}
+}
+
+// coalescer is implemented by clients that share fetch results.
+type coalescer interface {
+	// coalesceFetch returns the result of fetch for url, which may be shared
+	// with other calls.
+	coalesceFetch(ctx context.Context, url string, fetch func(context.Context) (*Response, error)) (*Response, error)
+	// discardFetch drops a completed result kept for url, e.g. because its
+	// body turned out to be corrupt.
+	discardFetch(url string)
+}
+
+func (c *DefaultClient) coalesceFetch(ctx context.Context, url string, fetch func(context.Context) (*Response, error)) (*Response, error) {
+	if c.coalesce == nil {
+		return fetch(ctx)
+	}
+	return c.coalesce.do(ctx, url, fetch)
+}
+
+func (c *DefaultClient) discardFetch(url string) {
+	if c.coalesce != nil {
+		c.coalesce.discard(url)
+	}
+}
+
+type coalesceGroup struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+type flight struct {
+	done chan struct{}
+	resp *Response
+	err  error
+}
+
+func (g *coalesceGroup) do(ctx context.Context, url string, fetch func(context.Context) (*Response, error)) (*Response, error) {
+	for {
+		g.mu.Lock()
+		f, ok := g.flights[url]
+		if !ok {
+			f = &flight{done: make(chan struct{})}
+			g.flights[url] = f
+			g.mu.Unlock()
+			g.run(ctx, url, f, fetch)
+			if f.err != nil {
+				return nil, f.err
+			}
+			return f.resp.clone(), nil
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if f.err == nil {
+			return f.resp.clone(), nil
+		}
+		// The caller that made the request gave up; that is no reason for
+		// this one to fail.
+		if isContextError(f.err) && ctx.Err() == nil {
+			continue
+		}
+		return nil, f.err
+	}
+}
+
+// run performs f's fetch, then keeps a successful result for the window.
+func (g *coalesceGroup) run(ctx context.Context, url string, f *flight, fetch func(context.Context) (*Response, error)) {
+	f.resp, f.err = fetch(ctx)
+	close(f.done)
+
+	forget := func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.flights[url] == f {
+			delete(g.flights, url)
+		}
+	}
+	if f.err != nil {
+		forget()
+		return
+	}
+	time.AfterFunc(g.window, forget)
+}
+
+// discard forgets the result kept for url, leaving a fetch still in flight
+// alone.
+func (g *coalesceGroup) discard(url string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if f, ok := g.flights[url]; ok {
+		select {
+		case <-f.done:
+			delete(g.flights, url)
+		default:
+		}
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// clone returns a deep copy of r, so callers sharing it cannot see each
+// other's changes.
+func (r *Response) clone() *Response {
+	c := *r
+	c.Header = r.Header.Clone()
+	c.Trailer = r.Trailer.Clone()
+	c.Body = bytes.Clone(r.Body)
+	return &c
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithCoalesceWindow(t *testing.T) {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		window    time.Duration
+		gap       time.Duration // between the sequential calls
+		wantCalls int64
+	}{
+		{
+			name:      "within window",
+			window:    time.Second,
+			gap:       10 * time.Millisecond,
+			wantCalls: 1,
+		},
+		{
+			name:      "outside window",
+			window:    50 * time.Millisecond,
+			gap:       150 * time.Millisecond,
+			wantCalls: 2,
+		},
+		{
+			name:      "disabled",
+			gap:       10 * time.Millisecond,
+			wantCalls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			c := NewDefaultClient(WithCoalesceWindow(tt.window))
+			first, err := FetchDataFrom(c, srv.URL)
+			if err != nil {
+				t.Fatalf("FetchDataFrom() error = %v", err)
+			}
+			first[0] = 'J'
+			time.Sleep(tt.gap)
+			second, err := FetchDataFrom(c, srv.URL)
+			if err != nil {
+				t.Fatalf("FetchDataFrom() error = %v", err)
+			}
+			if string(second) != "hello" {
+				t.Errorf("second body = %q, want an independent copy %q", second, "hello")
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("network calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+
+	t.Run("concurrent calls", func(t *testing.T) {
+		calls.Store(0)
+		c := NewDefaultClient(WithCoalesceWindow(time.Second))
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if body, err := FetchDataFrom(c, srv.URL); err != nil || string(body) != "hello" {
+					t.Errorf("FetchDataFrom = %q, %v", body, err)
+				}
+			}()
+		}
+		wg.Wait()
+		if got := calls.Load(); got != 1 {
+			t.Errorf("network calls = %d, want 1", got)
+		}
+	})
+
+	t.Run("failures are not shared", func(t *testing.T) {
+		var fails atomic.Int64
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fails.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer failing.Close()
+		c := NewDefaultClient(WithCoalesceWindow(time.Second))
+		for range 2 {
+			if _, err := FetchDataFrom(c, failing.URL); err == nil {
+				t.Fatal("expected an error")
+			}
+		}
+		if got := fails.Load(); got != 2 {
+			t.Errorf("network calls = %d, want 2", got)
+		}
+	})
+}
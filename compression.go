@@ -0,0 +1,180 @@
+package client
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// WithCompressionMetric reports, for every gzip-encoded response, the ratio
+// of compressed to decompressed body bytes as MetricCompressionRatio once the
+// body is fully read. The client negotiates gzip itself instead of leaving
+// it to the transport so the compressed size stays visible. Requests that
+// set their own Accept-Encoding are left alone. It needs WithMetrics.
+func WithCompressionMetric() Option {
+	return func(c *DefaultClient) {
+		c.compressionMetric = true
+	}
+}
+
+// WithRawBody disables automatic decompression, including the transport's
+// built-in gzip handling, so bodies are returned exactly as sent on the wire
+// and Content-Encoding is left in the response headers, e.g. for proxying.
+// It overrides WithCompressionMetric and WithEncodingPreferences.
+func WithRawBody() Option {
+	return func(c *DefaultClient) {
+		c.rawBody = true
+		c.transport.DisableCompression = true
+	}
+}
+
+// rawBodier is implemented by clients that can turn off decompression.
+type rawBodier interface {
+	rawBodyEnabled() bool
+}
+
+func (c *DefaultClient) rawBodyEnabled() bool {
+	return c.rawBody
+}
+
+// EncodingPref is a content coding and its preference weight for
+// WithEncodingPreferences.
+type EncodingPref struct {
+	// Encoding is one of "gzip", "deflate", "br" or "identity".
+	Encoding string
+	// Q is the weight between 0 and 1; higher is preferred.
+	Q float64
+}
+
+// decoders maps the content codings the client can decode to constructors.
+var decoders = map[string]func(io.Reader) (io.Reader, error){
+	"gzip":    func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	"x-gzip":  func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	"deflate": func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) },
+	"br":      func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+}
+
+// WithEncodingPreferences sends a q-weighted Accept-Encoding built from prefs,
+// in order (e.g. "br;q=1.0, gzip;q=0.8"), and decodes whichever of them the
+// server chose. Requests that set their own Accept-Encoding are left alone.
+// If an encoding cannot be decoded, every request fails with
+// ErrInvalidOption.
+func WithEncodingPreferences(prefs []EncodingPref) Option {
+	parts := make([]string, 0, len(prefs))
+	var unsupported []string
+	for _, pref := range prefs {
+		encoding := strings.ToLower(pref.Encoding)
+		if _, ok := decoders[encoding]; !ok && encoding != "identity" {
+			unsupported = append(unsupported, pref.Encoding)
+			continue
+		}
+		parts = append(parts, encoding+";q="+formatQ(pref.Q))
+	}
+	header := strings.Join(parts, ", ")
+	return func(c *DefaultClient) {
+		for _, encoding := range unsupported {
+			c.invalidOption(fmt.Errorf("unsupported content encoding %q", encoding))
+		}
+		c.acceptEncoding = header
+	}
+}
+
+// formatQ formats q as a qvalue: at most three decimals, at least one.
+func formatQ(q float64) string {
+	s := strconv.FormatFloat(min(max(q, 0), 1), 'f', 3, 64)
+	s = strings.TrimRight(s, "0")
+	if strings.HasSuffix(s, ".") {
+		s += "0"
+	}
+	return s
+}
+
+// requestCompression asks for compressed responses on req's behalf,
+// reporting whether it did.
+func (c *DefaultClient) requestCompression(req *http.Request) (*http.Request, bool) {
+	accept := c.acceptEncoding
+	if accept == "" && c.compressionMetric && c.metrics != nil {
+		accept = "gzip"
+	}
+	if accept == "" || c.rawBody || req.Method == http.MethodHead ||
+		req.Header.Get("Accept-Encoding") != "" || req.Header.Get("Range") != "" {
+		return req, false
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", accept)
+	return req, true
+}
+
+// decompress transparently decodes a response the client asked to be
+// compressed, the way the transport would have, and records the compression
+// ratio under WithCompressionMetric.
+func (c *DefaultClient) decompress(req *http.Request, resp *http.Response) {
+	newDecoder, ok := decoders[strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))]
+	if !ok {
+		return
+	}
+	body := &ratioBody{
+		compressed: &countingReader{r: resp.Body},
+		rc:         resp.Body,
+		newDecoder: newDecoder,
+	}
+	if c.compressionMetric && c.metrics != nil {
+		body.observe = func(ratio float64) {
+			c.metrics.Observe(MetricCompressionRatio, ratio, metricLabels(req, resp))
+		}
+	}
+	resp.Body = body
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// ratioBody decodes the body lazily, so Do does not block on the encoding
+// header, and reports compressed/decompressed bytes at EOF.
+type ratioBody struct {
+	compressed   *countingReader
+	rc           io.ReadCloser
+	newDecoder   func(io.Reader) (io.Reader, error)
+	dec          io.Reader
+	decompressed int64
+	observe      func(ratio float64)
+}
+
+func (b *ratioBody) Read(p []byte) (int, error) {
+	if b.dec == nil {
+		dec, err := b.newDecoder(b.compressed)
+		if err != nil {
+			return 0, err
+		}
+		b.dec = dec
+	}
+	n, err := b.dec.Read(p)
+	b.decompressed += int64(n)
+	if err == io.EOF && b.observe != nil && b.decompressed > 0 {
+		b.observe(float64(b.compressed.n) / float64(b.decompressed))
+		b.observe = nil
+	}
+	return n, err
+}
+
+func (b *ratioBody) Close() error {
+	return b.rc.Close()
+}
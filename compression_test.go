@@ -0,0 +1,216 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestWithCompressionMetric(t *testing.T) {
+	payload := []byte(strings.Repeat(`{"id":1,"title":"hello"},`, 200))
+	compressed := gzipBytes(t, payload)
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		gzip           bool
+		wantRatio      bool
+	}{
+		{
+			name:      "gzip response recorded",
+			gzip:      true,
+			wantRatio: true,
+		},
+		{
+			name:      "identity response not recorded",
+			gzip:      false,
+			wantRatio: false,
+		},
+		{
+			name:           "caller-set Accept-Encoding left alone",
+			acceptEncoding: "identity",
+			wantRatio:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.gzip && r.Header.Get("Accept-Encoding") == "gzip" {
+					w.Header().Set("Content-Encoding", "gzip")
+					w.Write(compressed)
+					return
+				}
+				w.Write(payload)
+			}))
+			defer srv.Close()
+
+			m := newRecordingMetrics()
+			c := NewDefaultClient(WithMetrics(m), WithCompressionMetric())
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			resp, err := Execute(c, req)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if string(resp.Body) != string(payload) {
+				t.Fatalf("body not decoded: got %d bytes, want %d", len(resp.Body), len(payload))
+			}
+			if resp.Header.Get("Content-Encoding") != "" {
+				t.Errorf("Content-Encoding = %q, want it stripped", resp.Header.Get("Content-Encoding"))
+			}
+
+			ratios := m.values(MetricCompressionRatio, map[string]string{"method": "GET", "status": "200", "operation": ""})
+			if !tt.wantRatio {
+				if len(ratios) != 0 {
+					t.Errorf("ratio recorded = %v, want none", ratios)
+				}
+				return
+			}
+			if len(ratios) != 1 {
+				t.Fatalf("ratio observations = %v, want 1", ratios)
+			}
+			want := float64(len(compressed)) / float64(len(payload))
+			if ratios[0] != want || ratios[0] <= 0 || ratios[0] >= 1 {
+				t.Errorf("ratio = %v, want %v", ratios[0], want)
+			}
+		})
+	}
+}
+
+func TestWithRawBody(t *testing.T) {
+	payload := []byte(strings.Repeat(`{"id":1,"title":"hello"},`, 200))
+	compressed := gzipBytes(t, payload)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		opts         []Option
+		wantBody     []byte
+		wantEncoding string
+	}{
+		{
+			name:     "decompressed by default",
+			wantBody: payload,
+		},
+		{
+			name:         "raw body",
+			opts:         []Option{WithRawBody()},
+			wantBody:     compressed,
+			wantEncoding: "gzip",
+		},
+		{
+			name:         "raw body overrides compression metric",
+			opts:         []Option{WithRawBody(), WithCompressionMetric(), WithMetrics(newRecordingMetrics())},
+			wantBody:     compressed,
+			wantEncoding: "gzip",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewDefaultClient(tt.opts...)
+			resp, err := FetchResponse(c, srv.URL)
+			if err != nil {
+				t.Fatalf("FetchResponse() error = %v", err)
+			}
+			if !bytes.Equal(resp.Body, tt.wantBody) {
+				t.Errorf("body has %d bytes, want %d", len(resp.Body), len(tt.wantBody))
+			}
+			if got := resp.Header.Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+
+			var streamed []byte
+			err = FetchProgressive(context.Background(), c, srv.URL, func(b []byte) error {
+				streamed = append(streamed, b...)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("FetchProgressive() error = %v", err)
+			}
+			if !bytes.Equal(streamed, tt.wantBody) {
+				t.Errorf("streamed body has %d bytes, want %d", len(streamed), len(tt.wantBody))
+			}
+		})
+	}
+}
+
+func TestWithEncodingPreferences_Header(t *testing.T) {
+	tests := []struct {
+		prefs []EncodingPref
+		want  string
+	}{
+		{prefs: []EncodingPref{{"br", 1}, {"gzip", 0.8}}, want: "br;q=1.0, gzip;q=0.8"},
+		{prefs: []EncodingPref{{"GZIP", 0.125}, {"deflate", 0.05}, {"identity", 0}}, want: "gzip;q=0.125, deflate;q=0.05, identity;q=0.0"},
+		{prefs: []EncodingPref{{"br", 2}, {"gzip", -1}}, want: "br;q=1.0, gzip;q=0.0"},
+	}
+	for _, tt := range tests {
+		var got string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r.Header.Get("Accept-Encoding")
+		}))
+		if _, err := FetchDataFrom(NewDefaultClient(WithEncodingPreferences(tt.prefs)), srv.URL); err != nil {
+			t.Fatalf("FetchDataFrom() error = %v", err)
+		}
+		srv.Close()
+		if got != tt.want {
+			t.Errorf("Accept-Encoding = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestWithEncodingPreferences_Decodes(t *testing.T) {
+	payload := []byte(strings.Repeat(`{"id":1,"title":"hello"},`, 200))
+	encoders := map[string]func(io.Writer) io.WriteCloser{
+		"br":      func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) },
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+	}
+	// The server uses the first encoding the client listed.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		first, _, _ := strings.Cut(r.Header.Get("Accept-Encoding"), ";")
+		w.Header().Set("Content-Encoding", first)
+		enc := encoders[first](w)
+		enc.Write(payload)
+		enc.Close()
+	}))
+	defer srv.Close()
+
+	for encoding := range encoders {
+		t.Run(encoding, func(t *testing.T) {
+			c := NewDefaultClient(WithEncodingPreferences([]EncodingPref{{encoding, 1}, {"gzip", 0.5}}))
+			resp, err := FetchResponse(c, srv.URL)
+			if err != nil {
+				t.Fatalf("FetchResponse() error = %v", err)
+			}
+			if !bytes.Equal(resp.Body, payload) {
+				t.Errorf("body has %d bytes, want the %d-byte payload", len(resp.Body), len(payload))
+			}
+			if got := resp.Header.Get("Content-Encoding"); got != "" {
+				t.Errorf("Content-Encoding = %q after decoding, want none", got)
+			}
+		})
+	}
+}
+
+func TestWithEncodingPreferences_Unsupported(t *testing.T) {
+	c := NewDefaultClient(WithEncodingPreferences([]EncodingPref{{"zstd", 1}}))
+	if _, err := c.Get("http://127.0.0.1:1/"); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Get() error = %v, want ErrInvalidOption", err)
+	}
+}
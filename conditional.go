@@ -0,0 +1,51 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// FetchConditional fetches url with If-None-Match set to etag. On a 304 it
+// returns notModified=true and no body; on a 200 it returns the fresh body
+// and the response's ETag. An empty etag performs an unconditional fetch.
+func FetchConditional(client HTTPClient, url, etag string) (body []byte, newETag string, notModified bool, err error) {
+	body, newETag, notModified, err = fetchConditional(client, url, etag)
+	if err != nil {
+		return nil, "", false, mapError(client, err)
+	}
+	return body, newETag, notModified, nil
+}
+
+func fetchConditional(client HTTPClient, url, etag string) (body []byte, newETag string, notModified bool, err error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("failed to create request: %w", err)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := send(client, req)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("failed to fetch data: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		newETag = resp.Header.Get("ETag")
+		if newETag == "" {
+			newETag = etag
+		}
+		return nil, newETag, true, nil
+	case http.StatusOK:
+		body, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, "", false, fmt.Errorf("failed to read response body: %w", err)
+		}
+		return body, resp.Header.Get("ETag"), false, nil
+	default:
+		return nil, "", false, statusError(client, resp)
+	}
+}
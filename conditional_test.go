@@ -0,0 +1,82 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchConditional(t *testing.T) {
+	const currentETag = `"v2"`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", currentETag)
+		if r.Header.Get("If-None-Match") == currentETag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(`[{"id":1}]`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name            string
+		etag            string
+		wantBody        string
+		wantETag        string
+		wantNotModified bool
+	}{
+		{
+			name:     "no etag fetches body",
+			etag:     "",
+			wantBody: `[{"id":1}]`,
+			wantETag: currentETag,
+		},
+		{
+			name:     "stale etag returns fresh body",
+			etag:     `"v1"`,
+			wantBody: `[{"id":1}]`,
+			wantETag: currentETag,
+		},
+		{
+			name:            "current etag is not modified",
+			etag:            currentETag,
+			wantETag:        currentETag,
+			wantNotModified: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, etag, notModified, err := FetchConditional(NewDefaultClient(), srv.URL, tt.etag)
+			if err != nil {
+				t.Fatalf("FetchConditional() error = %v", err)
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+			if etag != tt.wantETag {
+				t.Errorf("etag = %q, want %q", etag, tt.wantETag)
+			}
+			if notModified != tt.wantNotModified {
+				t.Errorf("notModified = %v, want %v", notModified, tt.wantNotModified)
+			}
+		})
+	}
+}
+
+func TestFetchConditional_UnexpectedStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	body, _, notModified, err := FetchConditional(NewDefaultClient(), srv.URL, `"v1"`)
+	if err == nil || !strings.Contains(err.Error(), "unexpected status code: 500") {
+		t.Errorf("FetchConditional() error = %v, want unexpected status code: 500", err)
+	}
+	if body != nil || notModified {
+		t.Errorf("FetchConditional() = %q, %v, want nil, false", body, notModified)
+	}
+}
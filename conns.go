@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// WithMaxConnsPerHost limits the total number of connections per host,
+// including those in use. Zero means no limit.
+func WithMaxConnsPerHost(n int) Option {
+	return func(c *DefaultClient) {
+		c.transport.MaxConnsPerHost = n
+	}
+}
+
+// WithKeepAlive sets the TCP keep-alive interval for new connections so
+// connections silently dropped by NAT are detected. Zero or negative
+// disables TCP keep-alives.
+func WithKeepAlive(interval time.Duration) Option {
+	return func(c *DefaultClient) {
+		if interval <= 0 {
+			interval = -1
+		}
+		c.dialer.KeepAlive = interval
+	}
+}
+
+// WithConnMaxLifetime recycles connections once they are older than d, so
+// traffic rebalances across backends after scaling events. A connection
+// that expires while idle is closed then; one that is busy is closed when
+// its response is done instead of being returned to the pool. Requests in
+// flight are never interrupted.
+func WithConnMaxLifetime(d time.Duration) Option {
+	return func(c *DefaultClient) {
+		c.connLifetime = d
+		next := c.dial
+		c.dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := next(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			lc := &lifetimeConn{Conn: conn, busy: true}
+			lc.timer = time.AfterFunc(d, lc.expire)
+			return lc, nil
+		}
+	}
+}
+
+// lifetimeConn closes itself once it has expired and is not serving a
+// request.
+type lifetimeConn struct {
+	net.Conn
+	timer *time.Timer
+
+	mu      sync.Mutex
+	busy    bool
+	expired bool
+}
+
+func (c *lifetimeConn) expire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expired = true
+	if !c.busy {
+		c.Conn.Close()
+	}
+}
+
+func (c *lifetimeConn) markBusy() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.busy = true
+}
+
+func (c *lifetimeConn) markIdle() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.busy = false
+	if c.expired {
+		c.Conn.Close()
+	}
+}
+
+func (c *lifetimeConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+func (c *lifetimeConn) Close() error {
+	c.timer.Stop()
+	return c.Conn.Close()
+}
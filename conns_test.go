@@ -0,0 +1,133 @@
+package client
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithMaxConnsPerHost(t *testing.T) {
+	c := NewDefaultClient(WithMaxConnsPerHost(4))
+	if got := c.transport.MaxConnsPerHost; got != 4 {
+		t.Errorf("MaxConnsPerHost = %d, want 4", got)
+	}
+}
+
+func TestWithConnMaxLifetime(t *testing.T) {
+	tests := []struct {
+		name      string
+		lifetime  time.Duration
+		wantConns int32
+	}{
+		{
+			name:      "connection recycled after lifetime",
+			lifetime:  20 * time.Millisecond,
+			wantConns: 2,
+		},
+		{
+			name:      "connection reused within lifetime",
+			lifetime:  time.Minute,
+			wantConns: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conns atomic.Int32
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`[]`))
+			}))
+			srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					conns.Add(1)
+				}
+			}
+			srv.Start()
+			defer srv.Close()
+
+			c := NewDefaultClient(WithConnMaxLifetime(tt.lifetime))
+
+			if _, err := FetchDataFrom(c, srv.URL); err != nil {
+				t.Fatalf("FetchDataFrom() first request error = %v", err)
+			}
+			time.Sleep(50 * time.Millisecond)
+			if _, err := FetchDataFrom(c, srv.URL); err != nil {
+				t.Fatalf("FetchDataFrom() second request error = %v", err)
+			}
+
+			if got := conns.Load(); got != tt.wantConns {
+				t.Errorf("connections opened = %d, want %d", got, tt.wantConns)
+			}
+		})
+	}
+}
+
+func TestWithConnMaxLifetime_UnrewindableBody(t *testing.T) {
+	var conns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	c := NewDefaultClient(WithConnMaxLifetime(20 * time.Millisecond))
+	for i := range 2 {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, io.NopCloser(strings.NewReader("payload")))
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "payload" {
+			t.Errorf("request %d body = %q, want %q", i, body, "payload")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if got := conns.Load(); got != 2 {
+		t.Errorf("connections opened = %d, want 2", got)
+	}
+}
+
+func TestWithKeepAlive(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		want     time.Duration
+	}{
+		{
+			name:     "interval set",
+			interval: 10 * time.Second,
+			want:     10 * time.Second,
+		},
+		{
+			name:     "zero disables",
+			interval: 0,
+			want:     -1,
+		},
+		{
+			name:     "negative disables",
+			interval: -time.Second,
+			want:     -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewDefaultClient(WithKeepAlive(tt.interval))
+			if got := c.dialer.KeepAlive; got != tt.want {
+				t.Errorf("dialer KeepAlive = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// WithDeadlinePropagation sends the time left until the request context's
+// deadline in the named header on every try, so the server can budget its
+// work. With WithPerTryTimeout the try's own, earlier deadline is sent
+// instead. The header is omitted when there is no deadline. For
+// Grpc-Timeout the value uses the gRPC encoding (e.g. "1500m"); for any
+// other header it is a whole number of milliseconds.
+func WithDeadlinePropagation(headerName string) Option {
+	return func(c *DefaultClient) {
+		c.deadlineHeader = http.CanonicalHeaderKey(headerName)
+	}
+}
+
+// propagateDeadline returns req carrying its remaining deadline, if any:
+// the earlier of its context's deadline and tryDeadline, when not zero.
+func (c *DefaultClient) propagateDeadline(req *http.Request, tryDeadline time.Time) *http.Request {
+	if c.deadlineHeader == "" {
+		return req
+	}
+	deadline, ok := req.Context().Deadline()
+	if !tryDeadline.IsZero() && (!ok || tryDeadline.Before(deadline)) {
+		deadline, ok = tryDeadline, true
+	}
+	if !ok {
+		return req
+	}
+	left := max(time.Until(deadline), 0)
+	req = req.Clone(req.Context())
+	if c.deadlineHeader == "Grpc-Timeout" {
+		req.Header.Set(c.deadlineHeader, grpcTimeout(left))
+	} else {
+		req.Header.Set(c.deadlineHeader, strconv.FormatInt(left.Milliseconds(), 10))
+	}
+	return req
+}
+
+// grpcTimeout encodes d as a gRPC timeout: at most 8 digits followed by a
+// unit, rounding up to the finest unit that fits.
+func grpcTimeout(d time.Duration) string {
+	const maxValue = 1e8 - 1
+	units := []struct {
+		size time.Duration
+		name string
+	}{
+		{time.Nanosecond, "n"},
+		{time.Microsecond, "u"},
+		{time.Millisecond, "m"},
+		{time.Second, "S"},
+		{time.Minute, "M"},
+		{time.Hour, "H"},
+	}
+	for _, u := range units {
+		if n := (d + u.size - 1) / u.size; n <= maxValue {
+			return strconv.FormatInt(int64(n), 10) + u.name
+		}
+	}
+	return strconv.FormatInt(maxValue, 10) + "H"
+}
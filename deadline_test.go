@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithDeadlinePropagation(t *testing.T) {
+	got := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		header  string
+		timeout time.Duration // of the request context, 0 for none
+		perTry  time.Duration // 0 for none
+		want    time.Duration // 0 for no header
+		unit    string
+		scale   time.Duration
+	}{
+		{
+			name:    "milliseconds",
+			header:  "X-Request-Timeout",
+			timeout: 2 * time.Second,
+			want:    2 * time.Second,
+			scale:   time.Millisecond,
+		},
+		{
+			name:    "grpc",
+			header:  "grpc-timeout",
+			timeout: 200 * time.Second,
+			want:    200 * time.Second,
+			unit:    "m",
+			scale:   time.Millisecond,
+		},
+		{
+			name:   "no deadline",
+			header: "X-Request-Timeout",
+		},
+		{
+			name:    "per-try timeout earlier",
+			header:  "X-Request-Timeout",
+			timeout: 10 * time.Second,
+			perTry:  2 * time.Second,
+			want:    2 * time.Second,
+			scale:   time.Millisecond,
+		},
+		{
+			name:    "context deadline earlier",
+			header:  "X-Request-Timeout",
+			timeout: 2 * time.Second,
+			perTry:  10 * time.Second,
+			want:    2 * time.Second,
+			scale:   time.Millisecond,
+		},
+		{
+			name:   "per-try timeout only",
+			header: "X-Request-Timeout",
+			perTry: 2 * time.Second,
+			want:   2 * time.Second,
+			scale:  time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			opts := []Option{WithDeadlinePropagation(tt.header)}
+			if tt.perTry > 0 {
+				opts = append(opts, WithPerTryTimeout(tt.perTry))
+			}
+			c := NewDefaultClient(opts...)
+			if _, err := FetchDataContext(ctx, c, srv.URL); err != nil {
+				t.Fatalf("FetchDataContext() error = %v", err)
+			}
+
+			h := <-got
+			value, ok := h[http.CanonicalHeaderKey(tt.header)]
+			if tt.want == 0 {
+				if ok {
+					t.Errorf("%s = %q, want it absent", tt.header, value)
+				}
+				return
+			}
+			n, err := strconv.ParseInt(strings.TrimSuffix(h.Get(tt.header), tt.unit), 10, 64)
+			if err != nil {
+				t.Fatalf("%s = %q: %v", tt.header, h.Get(tt.header), err)
+			}
+			if left := time.Duration(n) * tt.scale; left <= tt.want-time.Second || left > tt.want {
+				t.Errorf("%s = %q, want about %v", tt.header, h.Get(tt.header), tt.want)
+			}
+		})
+	}
+}
+
+func TestGRPCTimeout(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0n"},
+		{1500 * time.Millisecond, "1500000u"},
+		{99999999 * time.Nanosecond, "99999999n"},
+		{200 * time.Second, "200000m"},
+		{1000 * time.Hour, "3600000S"},
+		{time.Nanosecond*99999999 + 1, "100000u"},
+	}
+	for _, tt := range tests {
+		if got := grpcTimeout(tt.d); got != tt.want {
+			t.Errorf("grpcTimeout(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DiskCache is a Cache backed by one file per entry in a directory. Expired
+// entries are removed when read or when Prune is called.
+type DiskCache struct {
+	dir string
+	now func() time.Time
+
+	mu sync.RWMutex
+}
+
+// NewDiskCache returns a DiskCache storing entries in dir, creating it if
+// needed.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir, now: time.Now}, nil
+}
+
+func (d *DiskCache) Get(key string) ([]byte, bool) {
+	path := d.path(key)
+
+	d.mu.RLock()
+	data, err := os.ReadFile(path)
+	d.mu.RUnlock()
+	if err != nil || len(data) < 8 {
+		return nil, false
+	}
+
+	if d.expired(data) {
+		d.mu.Lock()
+		os.Remove(path)
+		d.mu.Unlock()
+		return nil, false
+	}
+	return data[8:], true
+}
+
+func (d *DiskCache) Set(key string, val []byte, ttl time.Duration) {
+	data := make([]byte, 8+len(val))
+	binary.BigEndian.PutUint64(data, uint64(d.now().Add(ttl).UnixNano()))
+	copy(data[8:], val)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Write to a temporary file and rename so readers never see a partial entry.
+	tmp, err := os.CreateTemp(d.dir, "tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), d.path(key)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+func (d *DiskCache) Delete(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	os.Remove(d.path(key))
+}
+
+// Prune removes all expired entries from disk.
+func (d *DiskCache) Prune() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	files, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".cache") {
+			continue
+		}
+		path := filepath.Join(d.dir, f.Name())
+		data, err := os.ReadFile(path)
+		if err != nil || len(data) < 8 || d.expired(data) {
+			os.Remove(path)
+		}
+	}
+	return nil
+}
+
+func (d *DiskCache) expired(data []byte) bool {
+	expires := int64(binary.BigEndian.Uint64(data))
+	return d.now().UnixNano() >= expires
+}
+
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+".cache")
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestDiskCache(t *testing.T) (*DiskCache, *time.Time) {
+	t.Helper()
+	c, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDiskCache() error = %v", err)
+	}
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestDiskCache(t *testing.T) {
+	c, _ := newTestDiskCache(t)
+
+	if _, ok := c.Get("missing"); ok {
+		t.Error("Get(missing) hit, want miss")
+	}
+
+	c.Set("key", []byte("value"), time.Minute)
+	if got, ok := c.Get("key"); !ok || string(got) != "value" {
+		t.Errorf("Get(key) = %q, %v, want value, true", got, ok)
+	}
+
+	c.Delete("key")
+	if _, ok := c.Get("key"); ok {
+		t.Error("Get(key) hit after Delete, want miss")
+	}
+}
+
+func TestDiskCache_Expiry(t *testing.T) {
+	c, now := newTestDiskCache(t)
+
+	c.Set("short", []byte("a"), time.Second)
+	c.Set("long", []byte("b"), time.Hour)
+
+	*now = now.Add(time.Minute)
+	if _, ok := c.Get("short"); ok {
+		t.Error("Get(short) hit after expiry, want miss")
+	}
+	if _, ok := c.Get("long"); !ok {
+		t.Error("Get(long) miss, want hit")
+	}
+}
+
+func TestDiskCache_Prune(t *testing.T) {
+	c, now := newTestDiskCache(t)
+
+	c.Set("a", []byte("a"), time.Second)
+	c.Set("b", []byte("b"), time.Second)
+	c.Set("c", []byte("c"), time.Hour)
+
+	*now = now.Add(time.Minute)
+	if err := c.Prune(); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("entries on disk after Prune = %d, want 1", len(files))
+	}
+}
+
+func TestDiskCache_Concurrent(t *testing.T) {
+	c, _ := newTestDiskCache(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("key-%d", i%4)
+			for j := 0; j < 20; j++ {
+				c.Set(key, []byte(key), time.Minute)
+				if got, ok := c.Get(key); ok && string(got) != key {
+					t.Errorf("Get(%s) = %q, want %q", key, got, key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestWithCache_DiskCache(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id":1}]`))
+	}))
+	defer srv.Close()
+
+	cache, _ := newTestDiskCache(t)
+	c := NewDefaultClient(WithCache(cache))
+
+	for i := 0; i < 2; i++ {
+		resp, err := FetchResponse(c, srv.URL)
+		if err != nil {
+			t.Fatalf("FetchResponse() request %d error = %v", i+1, err)
+		}
+		if string(resp.Body) != `[{"id":1}]` {
+			t.Errorf("request %d body = %q", i+1, resp.Body)
+		}
+		if got := resp.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("request %d Content-Type = %q, want application/json", i+1, got)
+		}
+	}
+
+	if got := hits.Load(); got != 1 {
+		t.Errorf("server hits = %d, want 1", got)
+	}
+}
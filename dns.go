@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// hostResolver is the subset of *net.Resolver used by the DNS cache.
+type hostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+type dnsEntry struct {
+	addrs      []string
+	resolved   time.Time
+	refreshing bool
+}
+
+// dnsCache caches successful host lookups for ttl. Entries older than half
+// the ttl are refreshed in the background while the cached addresses keep
+// being served; entries older than ttl are evicted. Failures are never cached.
+type dnsCache struct {
+	resolver hostResolver
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*dnsEntry
+}
+
+// WithDNSCache caches DNS resolution results in the dialer for ttl.
+func WithDNSCache(ttl time.Duration) Option {
+	return func(c *DefaultClient) {
+		c.dnsCache = newDNSCache(net.DefaultResolver, ttl)
+		next := c.dial
+		c.dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return c.dnsCache.dial(ctx, network, addr, next)
+		}
+	}
+}
+
+func newDNSCache(resolver hostResolver, ttl time.Duration) *dnsCache {
+	return &dnsCache{
+		resolver: resolver,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[string]*dnsEntry),
+	}
+}
+
+func (d *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	d.mu.Lock()
+	if e, ok := d.entries[host]; ok {
+		age := d.now().Sub(e.resolved)
+		if age < d.ttl {
+			if age >= d.ttl/2 && !e.refreshing {
+				e.refreshing = true
+				go d.refresh(host)
+			}
+			addrs := e.addrs
+			d.mu.Unlock()
+			return addrs, nil
+		}
+		delete(d.entries, host)
+	}
+	d.mu.Unlock()
+
+	addrs, err := d.resolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	d.store(host, addrs)
+	return addrs, nil
+}
+
+func (d *dnsCache) refresh(host string) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.ttl)
+	defer cancel()
+
+	addrs, err := d.resolver.LookupHost(ctx, host)
+	if err != nil {
+		d.mu.Lock()
+		if e, ok := d.entries[host]; ok {
+			e.refreshing = false
+		}
+		d.mu.Unlock()
+		return
+	}
+	d.store(host, addrs)
+}
+
+func (d *dnsCache) store(host string, addrs []string) {
+	d.mu.Lock()
+	d.entries[host] = &dnsEntry{addrs: addrs, resolved: d.now()}
+	d.mu.Unlock()
+}
+
+func (d *dnsCache) dial(ctx context.Context, network, addr string, next dialFunc) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return next(ctx, network, addr)
+	}
+
+	addrs, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("no addresses found for " + host)
+	}
+
+	var lastErr error
+	for _, ip := range addrs {
+		conn, err := next(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingResolver struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (r *countingResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.calls.Add(1)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return []string{"127.0.0.1"}, nil
+}
+
+func TestWithDNSCache(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	url := "http://cached.test:" + port
+
+	resolver := &countingResolver{}
+	c := NewDefaultClient(WithDNSCache(time.Minute))
+	c.dnsCache.resolver = resolver
+	c.transport.DisableKeepAlives = true
+
+	for i := 0; i < 2; i++ {
+		resp, err := c.Get(url)
+		if err != nil {
+			t.Fatalf("Get() request %d error = %v", i+1, err)
+		}
+		resp.Body.Close()
+	}
+
+	if got := resolver.calls.Load(); got != 1 {
+		t.Errorf("resolver called %d times, want 1", got)
+	}
+}
+
+func TestDNSCache_Lookup(t *testing.T) {
+	ttl := time.Minute
+
+	tests := []struct {
+		name      string
+		advance   time.Duration
+		wantCalls int32
+	}{
+		{
+			name:      "within ttl served from cache",
+			advance:   ttl / 4,
+			wantCalls: 1,
+		},
+		{
+			name:      "expired entry is evicted and resolved again",
+			advance:   ttl + time.Second,
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := &countingResolver{}
+			cache := newDNSCache(resolver, ttl)
+			now := time.Now()
+			cache.now = func() time.Time { return now }
+
+			if _, err := cache.lookup(context.Background(), "host.test"); err != nil {
+				t.Fatalf("lookup() error = %v", err)
+			}
+			now = now.Add(tt.advance)
+			if _, err := cache.lookup(context.Background(), "host.test"); err != nil {
+				t.Fatalf("lookup() error = %v", err)
+			}
+
+			if got := resolver.calls.Load(); got != tt.wantCalls {
+				t.Errorf("resolver called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDNSCache_FailuresNotCached(t *testing.T) {
+	resolver := &countingResolver{err: errors.New("no such host")}
+	cache := newDNSCache(resolver, time.Minute)
+
+	if _, err := cache.lookup(context.Background(), "host.test"); err == nil {
+		t.Fatal("lookup() expected error")
+	}
+
+	resolver.err = nil
+	addrs, err := cache.lookup(context.Background(), "host.test")
+	if err != nil {
+		t.Fatalf("lookup() error = %v", err)
+	}
+	if len(addrs) != 1 || addrs[0] != "127.0.0.1" {
+		t.Errorf("lookup() = %v, want [127.0.0.1]", addrs)
+	}
+	if got := resolver.calls.Load(); got != 2 {
+		t.Errorf("resolver called %d times, want 2", got)
+	}
+}
+
+func TestDNSCache_BackgroundRefresh(t *testing.T) {
+	ttl := time.Minute
+	resolver := &countingResolver{}
+	cache := newDNSCache(resolver, ttl)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	if _, err := cache.lookup(context.Background(), "host.test"); err != nil {
+		t.Fatalf("lookup() error = %v", err)
+	}
+	now = now.Add(ttl * 3 / 4)
+	if _, err := cache.lookup(context.Background(), "host.test"); err != nil {
+		t.Fatalf("lookup() error = %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for resolver.calls.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("background refresh did not run")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
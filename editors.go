@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// RequestEditorFn mutates an outgoing request before it is sent.
+type RequestEditorFn func(ctx context.Context, req *http.Request) error
+
+// WithRequestEditors runs editors, in order, on a copy of every request sent
+// through Do. An editor error aborts the request and is returned unchanged.
+// Repeated uses append to the list.
+func WithRequestEditors(editors ...RequestEditorFn) Option {
+	return func(c *DefaultClient) {
+		c.requestEditors = append(c.requestEditors, editors...)
+	}
+}
+
+func (c *DefaultClient) editRequest(req *http.Request) (*http.Request, error) {
+	if len(c.requestEditors) == 0 {
+		return req, nil
+	}
+	req = req.Clone(req.Context())
+	for _, edit := range c.requestEditors {
+		if err := edit(req.Context(), req); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// ResponseEditorFn inspects or mutates a response before its body is read.
+type ResponseEditorFn func(ctx context.Context, resp *http.Response) error
+
+// WithResponseEditors runs editors, in order, on every response returned by
+// Do, before the body is read. An editor error closes the response and is
+// returned unchanged. Repeated uses append to the list.
+func WithResponseEditors(editors ...ResponseEditorFn) Option {
+	return func(c *DefaultClient) {
+		c.responseEditors = append(c.responseEditors, editors...)
+	}
+}
+
+func (c *DefaultClient) editResponse(req *http.Request, resp *http.Response) error {
+	for _, edit := range c.responseEditors {
+		if err := edit(req.Context(), resp); err != nil {
+			resp.Body.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrUnexpectedHeader is returned when a response fails a header check set
+// with WithRequiredResponseHeader or WithResponseHeaderMatcher.
+var ErrUnexpectedHeader = errors.New("unexpected response header")
+
+// WithRequiredResponseHeader fails every successful response whose name
+// header is not exactly expected, e.g. to catch a changed X-Api-Version.
+func WithRequiredResponseHeader(name, expected string) Option {
+	return WithResponseHeaderMatcher(name, func(value string) bool { return value == expected })
+}
+
+// WithResponseHeaderMatcher fails every successful response whose name
+// header, or "" if it is missing, is rejected by match. Use it to accept a
+// range of versions.
+func WithResponseHeaderMatcher(name string, match func(value string) bool) Option {
+	return WithResponseEditors(func(_ context.Context, resp *http.Response) error {
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil
+		}
+		if value := resp.Header.Get(name); !match(value) {
+			return fmt.Errorf("%w: %s: %q", ErrUnexpectedHeader, name, value)
+		}
+		return nil
+	})
+}
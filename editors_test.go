@@ -0,0 +1,213 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWithRequestEditors(t *testing.T) {
+	errDenied := errors.New("denied")
+	appendTrace := func(step string) RequestEditorFn {
+		return func(ctx context.Context, req *http.Request) error {
+			req.Header.Add("X-Trace", step)
+			return nil
+		}
+	}
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantTrace string
+		wantErr   error
+	}{
+		{
+			name:      "editors run in order",
+			opts:      []Option{WithRequestEditors(appendTrace("a"), appendTrace("b")), WithRequestEditors(appendTrace("c"))},
+			wantTrace: "a,b,c",
+		},
+		{
+			name: "error short-circuits",
+			opts: []Option{WithRequestEditors(
+				appendTrace("a"),
+				func(context.Context, *http.Request) error { return errDenied },
+				func(context.Context, *http.Request) error { t.Error("editor after failure ran"); return nil },
+			)},
+			wantErr: errDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			var gotTrace string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				gotTrace = strings.Join(r.Header.Values("X-Trace"), ",")
+			}))
+			defer srv.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			resp, err := NewDefaultClient(tt.opts...).Do(req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Do() error = %v, want %v", err, tt.wantErr)
+				}
+				if hits.Load() != 0 {
+					t.Error("request was sent after editor error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+			if gotTrace != tt.wantTrace {
+				t.Errorf("X-Trace = %q, want %q", gotTrace, tt.wantTrace)
+			}
+			if req.Header.Get("X-Trace") != "" {
+				t.Error("editors mutated the caller's request")
+			}
+		})
+	}
+}
+
+func TestWithResponseEditors(t *testing.T) {
+	errChallenge := errors.New("auth challenge")
+	appendTrace := func(step string) ResponseEditorFn {
+		return func(ctx context.Context, resp *http.Response) error {
+			resp.Header.Add("X-Trace", step)
+			return nil
+		}
+	}
+	rejectChallenge := func(ctx context.Context, resp *http.Response) error {
+		if resp.Header.Get("WWW-Authenticate") != "" {
+			return errChallenge
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name      string
+		challenge bool
+		wantTrace string
+		wantErr   error
+	}{
+		{
+			name:      "editors run in order",
+			wantTrace: "a,b",
+		},
+		{
+			name:      "error short-circuits",
+			challenge: true,
+			wantErr:   errChallenge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.challenge {
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				}
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(WithResponseEditors(
+				appendTrace("a"),
+				rejectChallenge,
+				appendTrace("b"),
+			))
+			resp, err := c.Get(srv.URL)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Get() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			resp.Body.Close()
+			if got := strings.Join(resp.Header.Values("X-Trace"), ","); got != tt.wantTrace {
+				t.Errorf("X-Trace = %q, want %q", got, tt.wantTrace)
+			}
+		})
+	}
+}
+
+func TestWithRequiredResponseHeader(t *testing.T) {
+	atLeastV2 := func(v string) bool { return strings.HasPrefix(v, "2") || strings.HasPrefix(v, "3") }
+	tests := []struct {
+		name    string
+		version string
+		status  int
+		opt     Option
+		wantErr bool
+	}{
+		{
+			name:    "exact match",
+			version: "2",
+			status:  http.StatusOK,
+			opt:     WithRequiredResponseHeader("X-Api-Version", "2"),
+		},
+		{
+			name:    "mismatch",
+			version: "3",
+			status:  http.StatusOK,
+			opt:     WithRequiredResponseHeader("X-Api-Version", "2"),
+			wantErr: true,
+		},
+		{
+			name:    "missing",
+			status:  http.StatusOK,
+			opt:     WithRequiredResponseHeader("X-Api-Version", "2"),
+			wantErr: true,
+		},
+		{
+			name:    "matcher accepts range",
+			version: "3.1",
+			status:  http.StatusOK,
+			opt:     WithResponseHeaderMatcher("X-Api-Version", atLeastV2),
+		},
+		{
+			name:    "matcher rejects",
+			version: "1.9",
+			status:  http.StatusOK,
+			opt:     WithResponseHeaderMatcher("X-Api-Version", atLeastV2),
+			wantErr: true,
+		},
+		{
+			name:   "error responses not checked",
+			status: http.StatusNotFound,
+			opt:    WithRequiredResponseHeader("X-Api-Version", "2"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.version != "" {
+					w.Header().Set("X-Api-Version", tt.version)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			resp, err := NewDefaultClient(tt.opt).Get(srv.URL)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnexpectedHeader) {
+					t.Fatalf("err = %v, want ErrUnexpectedHeader", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			resp.Body.Close()
+		})
+	}
+}
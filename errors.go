@@ -0,0 +1,84 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HTTPError is returned when a response has an unexpected status code.
+type HTTPError struct {
+	StatusCode int
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// WithErrorMapper runs fn on every error before it is returned from the
+// fetch helpers, letting callers convert errors into their own domain types.
+// Wrap with %w to keep errors.Is/errors.As working on the original error.
+func WithErrorMapper(fn func(error) error) Option {
+	return func(c *DefaultClient) {
+		c.errorMapper = fn
+	}
+}
+
+// errorMapper is implemented by clients that rewrite returned errors.
+type errorMapper interface {
+	mapError(err error) error
+}
+
+func mapError(client HTTPClient, err error) error {
+	if m, ok := client.(errorMapper); ok {
+		return m.mapError(err)
+	}
+	return err
+}
+
+func (c *DefaultClient) mapError(err error) error {
+	if c.errorMapper == nil {
+		return err
+	}
+	return c.errorMapper(err)
+}
+
+// maxErrorBody bounds how much of an error response is read for
+// WithErrorDecoder.
+const maxErrorBody = 64 << 10
+
+// WithErrorDecoder lets the fetch helpers turn unexpected responses into
+// application errors. decode receives the status code and up to 64 KiB of
+// the raw body; returning nil falls back to *HTTPError.
+func WithErrorDecoder(decode func(status int, body []byte) error) Option {
+	return func(c *DefaultClient) {
+		c.errorDecoder = decode
+	}
+}
+
+// errorDecoder is implemented by clients that decode error responses.
+type errorDecoder interface {
+	decodeError(resp *http.Response) error
+}
+
+// statusError returns the error for an unexpected response status. It may
+// read resp.Body but does not close it.
+func statusError(client HTTPClient, resp *http.Response) error {
+	if d, ok := client.(errorDecoder); ok {
+		if err := d.decodeError(resp); err != nil {
+			return err
+		}
+	}
+	return &HTTPError{StatusCode: resp.StatusCode}
+}
+
+func (c *DefaultClient) decodeError(resp *http.Response) error {
+	if c.errorDecoder == nil {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if err != nil {
+		return nil
+	}
+	return c.errorDecoder(resp.StatusCode, body)
+}
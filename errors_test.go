@@ -0,0 +1,135 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type apiError struct {
+	Code string
+	err  error
+}
+
+func (e *apiError) Error() string { return e.Code + ": " + e.err.Error() }
+func (e *apiError) Unwrap() error { return e.err }
+
+func TestWithErrorMapper(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	c := NewDefaultClient(WithErrorMapper(func(err error) error {
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+			return &apiError{Code: "NOT_FOUND", err: err}
+		}
+		return fmt.Errorf("mapped: %w", err)
+	}))
+
+	_, err := FetchDataFrom(c, srv.URL)
+
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("FetchDataFrom() error = %v, want *apiError", err)
+	}
+	if apiErr.Code != "NOT_FOUND" {
+		t.Errorf("apiError.Code = %q, want NOT_FOUND", apiErr.Code)
+	}
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("FetchDataFrom() error = %v, want to unwrap to *HTTPError", err)
+	}
+	if httpErr.StatusCode != http.StatusNotFound {
+		t.Errorf("HTTPError.StatusCode = %d, want %d", httpErr.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestWithErrorMapper_NotCalledOnSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	called := false
+	c := NewDefaultClient(WithErrorMapper(func(err error) error {
+		called = true
+		return err
+	}))
+
+	if _, err := FetchDataFrom(c, srv.URL); err != nil {
+		t.Fatalf("FetchDataFrom() error = %v", err)
+	}
+	if called {
+		t.Error("error mapper called on success")
+	}
+}
+
+type problemError struct {
+	Status  int
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *problemError) Error() string { return e.Code + ": " + e.Message }
+
+func decodeProblem(status int, body []byte) error {
+	var p problemError
+	if err := json.Unmarshal(body, &p); err != nil || p.Code == "" {
+		return nil
+	}
+	p.Status = status
+	return &p
+}
+
+func TestWithErrorDecoder(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantCode string
+	}{
+		{
+			name:     "structured error decoded",
+			status:   http.StatusTooManyRequests,
+			body:     `{"code":"RATE_LIMIT","message":"slow down"}`,
+			wantCode: "RATE_LIMIT",
+		},
+		{
+			name:   "undecodable body falls back to HTTPError",
+			status: http.StatusBadGateway,
+			body:   `<html>bad gateway</html>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			_, err := FetchDataFrom(NewDefaultClient(WithErrorDecoder(decodeProblem)), srv.URL)
+
+			if tt.wantCode == "" {
+				var httpErr *HTTPError
+				if !errors.As(err, &httpErr) || httpErr.StatusCode != tt.status {
+					t.Fatalf("FetchDataFrom() error = %v, want HTTPError %d", err, tt.status)
+				}
+				return
+			}
+			var problem *problemError
+			if !errors.As(err, &problem) {
+				t.Fatalf("FetchDataFrom() error = %v, want *problemError", err)
+			}
+			if problem.Code != tt.wantCode || problem.Status != tt.status || problem.Message != "slow down" {
+				t.Errorf("problem = %+v", problem)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultRevalidateWindow is how long a stale entry with an ETag or
+// Last-Modified is kept by default so it can be revalidated instead of
+// refetched.
+const defaultRevalidateWindow = 24 * time.Hour
+
+// WithRevalidateWindow sets how long WithCache keeps a stale response with
+// an ETag or Last-Modified, 24 hours by default, so that it can be
+// revalidated with a conditional request instead of refetched. Zero drops
+// stale responses right away.
+func WithRevalidateWindow(d time.Duration) Option {
+	return func(c *DefaultClient) {
+		c.revalidateWindow = d
+	}
+}
+
+// Freshness describes where a response body came from.
+type Freshness struct {
+	// FromCache reports whether the body was served from the client cache.
+	FromCache bool
+	// Age is how old the data is: the time since it was cached plus any
+	// Age the server reported.
+	Age time.Duration
+	// Revalidated reports whether a stale cached entry was confirmed
+	// unchanged by the server with a 304.
+	Revalidated bool
+}
+
+// FetchWithFreshness fetches url like FetchDataFrom and reports whether the
+// body came from the cache configured with WithCache, and how fresh it is.
+func FetchWithFreshness(client HTTPClient, url string) ([]byte, Freshness, error) {
+	var f Freshness
+	ctx := context.WithValue(context.Background(), freshnessKey{}, &f)
+	resp, err := fetch(ctx, client, url)
+	if err != nil {
+		return nil, Freshness{}, mapError(client, err)
+	}
+	return resp.Body, f, nil
+}
+
+type freshnessKey struct{}
+
+func reportFreshness(req *http.Request, f Freshness) {
+	if dst, ok := req.Context().Value(freshnessKey{}).(*Freshness); ok {
+		*dst = f
+	}
+}
+
+// headerAge returns the Age header as a duration, or zero.
+func headerAge(header http.Header) time.Duration {
+	secs, err := strconv.Atoi(header.Get("Age"))
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+func hasValidators(header http.Header) bool {
+	return header.Get("ETag") != "" || header.Get("Last-Modified") != ""
+}
+
+// conditionalRequest returns req revalidating a cached response with header,
+// unless the caller already made req conditional.
+func conditionalRequest(req *http.Request, header http.Header) *http.Request {
+	if req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return req
+	}
+	req = req.Clone(req.Context())
+	if etag := header.Get("ETag"); etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := header.Get("Last-Modified"); lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+	return req
+}
+
+// Cache entries are a line holding when the entry was stored and until when
+// it is fresh, in Unix nanoseconds, followed by the dumped response, which
+// keeps the times out of the headers callers see.
+
+func encodeEntry(storedAt, freshUntil time.Time, dump []byte) []byte {
+	line := fmt.Sprintf("%d %d\n", storedAt.UnixNano(), freshUntil.UnixNano())
+	return append([]byte(line), dump...)
+}
+
+// decodeEntry splits data into its times and dumped response. Entries
+// without the times line come back whole, with zero times.
+func decodeEntry(data []byte) (storedAt, freshUntil time.Time, dump []byte) {
+	line, rest, ok := bytes.Cut(data, []byte("\n"))
+	if !ok {
+		return time.Time{}, time.Time{}, data
+	}
+	var stored, fresh int64
+	if _, err := fmt.Sscanf(string(line), "%d %d", &stored, &fresh); err != nil {
+		return time.Time{}, time.Time{}, data
+	}
+	return time.Unix(0, stored), time.Unix(0, fresh), rest
+}
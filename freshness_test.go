@@ -0,0 +1,183 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchWithFreshness(t *testing.T) {
+	var hits, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(`[{"id":1}]`))
+	}))
+	defer srv.Close()
+
+	c := NewDefaultClient(WithCache(NewMemoryCache()))
+	now := time.Now()
+	c.cached.now = func() time.Time { return now }
+
+	steps := []struct {
+		name     string
+		advance  time.Duration
+		want     Freshness
+		wantHits int32
+		want304  int32
+	}{
+		{
+			name:     "fresh network response",
+			want:     Freshness{},
+			wantHits: 1,
+		},
+		{
+			name:     "cache hit",
+			advance:  10 * time.Second,
+			want:     Freshness{FromCache: true, Age: 10 * time.Second},
+			wantHits: 1,
+		},
+		{
+			name:     "stale entry revalidated",
+			advance:  time.Minute,
+			want:     Freshness{FromCache: true, Revalidated: true},
+			wantHits: 2,
+			want304:  1,
+		},
+		{
+			name:     "revalidated entry fresh again",
+			advance:  5 * time.Second,
+			want:     Freshness{FromCache: true, Age: 5 * time.Second},
+			wantHits: 2,
+			want304:  1,
+		},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		body, got, err := FetchWithFreshness(c, srv.URL)
+		if err != nil {
+			t.Fatalf("%s: FetchWithFreshness() error = %v", step.name, err)
+		}
+		if string(body) != `[{"id":1}]` {
+			t.Errorf("%s: body = %q", step.name, body)
+		}
+		if got != step.want {
+			t.Errorf("%s: freshness = %+v, want %+v", step.name, got, step.want)
+		}
+		if h, n := hits.Load(), notModified.Load(); h != step.wantHits || n != step.want304 {
+			t.Errorf("%s: server hits = %d (304s = %d), want %d (%d)", step.name, h, n, step.wantHits, step.want304)
+		}
+	}
+}
+
+func TestWithRevalidateWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		advance  time.Duration
+		wantHits int32
+		want304  int32
+	}{
+		{
+			name:     "default window revalidates",
+			advance:  time.Hour,
+			wantHits: 2,
+			want304:  1,
+		},
+		{
+			name:     "past custom window refetched",
+			opts:     []Option{WithRevalidateWindow(time.Minute)},
+			advance:  time.Hour,
+			wantHits: 2,
+		},
+		{
+			name:     "zero window refetched",
+			opts:     []Option{WithRevalidateWindow(0)},
+			advance:  2 * time.Minute,
+			wantHits: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits, notModified atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				w.Header().Set("Cache-Control", "max-age=60")
+				w.Header().Set("ETag", `"v1"`)
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					notModified.Add(1)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Write([]byte(`[{"id":1}]`))
+			}))
+			defer srv.Close()
+
+			cache := NewMemoryCache()
+			now := time.Now()
+			cache.now = func() time.Time { return now }
+			c := NewDefaultClient(append([]Option{WithCache(cache)}, tt.opts...)...)
+			c.cached.now = func() time.Time { return now }
+
+			for i := 0; i < 2; i++ {
+				if _, err := FetchDataFrom(c, srv.URL); err != nil {
+					t.Fatalf("FetchDataFrom() request %d error = %v", i+1, err)
+				}
+				now = now.Add(tt.advance)
+			}
+			if h, n := hits.Load(), notModified.Load(); h != tt.wantHits || n != tt.want304 {
+				t.Errorf("server hits = %d (304s = %d), want %d (%d)", h, n, tt.wantHits, tt.want304)
+			}
+		})
+	}
+}
+
+func TestWithCache_NoInternalHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte(`[{"id":1}]`))
+	}))
+	defer srv.Close()
+
+	cache := NewMemoryCache()
+	c := NewDefaultClient(WithCache(cache))
+	for i := 0; i < 2; i++ {
+		resp, err := c.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Get() request %d error = %v", i+1, err)
+		}
+		resp.Body.Close()
+		for name := range resp.Header {
+			if strings.HasPrefix(name, "X-Cache-") {
+				t.Errorf("Get() request %d header %s is internal", i+1, name)
+			}
+		}
+	}
+
+	data, ok := cache.Get(srv.URL)
+	if !ok {
+		t.Fatal("cache.Get() found no entry")
+	}
+	_, _, dump := decodeEntry(data)
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), nil)
+	if err != nil {
+		t.Fatalf("ReadResponse() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.Header.Get("Cache-Control") != "max-age=60" || strings.Contains(string(dump), "X-Cache-") {
+		t.Errorf("cached response = %q, want the server headers only", dump)
+	}
+}
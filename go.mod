@@ -2,6 +2,11 @@ module github.com/bharath/go-http-client
 
 go 1.24.1
 
-require github.com/therewardstore/httpmatter v0.1.3
+require (
+	github.com/andybalholm/brotli v1.2.5
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
+	github.com/therewardstore/httpmatter v0.1.3
+	go.uber.org/goleak v1.3.0
+)
 
 require github.com/jarcoal/httpmock v1.4.0 // indirect
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+func TestWithRequestCompression(t *testing.T) {
+	const threshold = 1024
+	var failFirst atomic.Int64
+	type upload struct {
+		encoding string
+		body     []byte
+	}
+	got := make(chan upload, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("gzip.NewReader() error = %v", err)
+				return
+			}
+			body = zr
+		}
+		b, err := io.ReadAll(body)
+		if err != nil {
+			t.Errorf("ReadAll() error = %v", err)
+		}
+		got <- upload{encoding: r.Header.Get("Content-Encoding"), body: b}
+		if failFirst.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		size         int
+		streaming    bool // hide the length from the client
+		fails        int64
+		wantEncoding string
+	}{
+		{
+			name:      "streaming just under threshold",
+			size:      threshold - 1,
+			streaming: true,
+		},
+		{
+			name:         "streaming at threshold",
+			size:         threshold,
+			streaming:    true,
+			wantEncoding: "gzip",
+		},
+		{
+			name:         "streaming just over threshold",
+			size:         threshold + 1,
+			streaming:    true,
+			wantEncoding: "gzip",
+		},
+		{
+			name: "known length under threshold",
+			size: threshold - 1,
+		},
+		{
+			name:         "known length over threshold",
+			size:         4 * threshold,
+			wantEncoding: "gzip",
+		},
+		{
+			name:         "retried body compressed again",
+			size:         4 * threshold,
+			fails:        1,
+			wantEncoding: "gzip",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failFirst.Store(tt.fails)
+			payload := bytes.Repeat([]byte("a"), tt.size)
+			var body io.Reader = bytes.NewReader(payload)
+			if tt.streaming {
+				body = io.MultiReader(body)
+			}
+			req, err := http.NewRequest(http.MethodPut, srv.URL, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := NewDefaultClient(WithRequestCompression(threshold), WithRetry(2, fastBackoff))
+			resp, err := c.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+
+			for i := int64(0); i <= tt.fails; i++ {
+				u := <-got
+				if u.encoding != tt.wantEncoding {
+					t.Errorf("try %d: Content-Encoding = %q, want %q", i+1, u.encoding, tt.wantEncoding)
+				}
+				if !bytes.Equal(u.body, payload) {
+					t.Errorf("try %d: server got %d bytes, want the %d-byte payload", i+1, len(u.body), len(payload))
+				}
+			}
+		})
+	}
+}
+
+func TestWithRequestCompression_NoLeakOnEarlyError(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	// The first failure opens the breaker, so the other requests fail
+	// before the transport reads their compressed bodies.
+	c := NewDefaultClient(WithRequestCompression(16), WithCircuitBreaker(1, time.Hour))
+	defer c.transport.CloseIdleConnections()
+	for range 20 {
+		req, err := http.NewRequest(http.MethodPut, srv.URL, io.NopCloser(strings.NewReader(strings.Repeat("x", 64))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp, err := c.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}
+}
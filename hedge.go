@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Hedging counters reported through Metrics with Add.
+const (
+	MetricHedgeLaunches = "http_client_hedge_launches_total"
+	MetricHedgeWins     = "http_client_hedge_wins_total"
+)
+
+// WithHedging sends a second, hedge copy of an idempotent request when the
+// first has not answered within delay, and uses whichever answers first with
+// a non-5xx response. The loser is cancelled. Requests with a body are only
+// hedged when it can be replayed through GetBody. With WithMetrics, hedge
+// launches and hedges that won are counted.
+func WithHedging(delay time.Duration) Option {
+	return func(c *DefaultClient) {
+		c.hedgeDelay = delay
+	}
+}
+
+type hedgeResult struct {
+	resp   *http.Response
+	err    error
+	index  int
+	cancel context.CancelFunc
+}
+
+func (r hedgeResult) won() bool {
+	return r.err == nil && r.resp.StatusCode < 500
+}
+
+// hedgedTry runs try for req, racing it against a hedge once hedgeDelay
+// elapses.
+func (c *DefaultClient) hedgedTry(req *http.Request, try func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	if c.hedgeDelay <= 0 || !isIdempotent(req.Method) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return try(req)
+	}
+
+	results := make(chan hedgeResult, 2)
+	var cancels []context.CancelFunc
+	launch := func(r *http.Request) {
+		ctx, cancel := context.WithCancel(r.Context())
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := try(r.WithContext(ctx))
+			results <- hedgeResult{resp: resp, err: err, index: index, cancel: cancel}
+		}()
+	}
+	launch(req)
+
+	timer := time.NewTimer(c.hedgeDelay)
+	defer timer.Stop()
+	pending := 1
+	for {
+		select {
+		case <-timer.C:
+			hreq, err := rewind(req)
+			if err != nil {
+				continue
+			}
+			launch(hreq)
+			pending++
+			c.countHedge(MetricHedgeLaunches, req)
+		case r := <-results:
+			pending--
+			if !r.won() && pending > 0 {
+				discard(r)
+				continue
+			}
+			// The first attempt failing before the hedge is due also ends
+			// the race, leaving the retry policy to decide what happens next.
+			for i, cancel := range cancels {
+				if i != r.index {
+					cancel()
+				}
+			}
+			go func(pending int) {
+				for ; pending > 0; pending-- {
+					discard(<-results)
+				}
+			}(pending)
+			if r.err != nil {
+				r.cancel()
+				return nil, r.err
+			}
+			if r.won() && r.index > 0 {
+				c.countHedge(MetricHedgeWins, req)
+			}
+			r.resp.Body = &cancelBody{rc: r.resp.Body, cancel: r.cancel}
+			return r.resp, nil
+		}
+	}
+}
+
+func discard(r hedgeResult) {
+	if r.resp != nil {
+		r.resp.Body.Close()
+	}
+	r.cancel()
+}
+
+func (c *DefaultClient) countHedge(name string, req *http.Request) {
+	if c.metrics != nil {
+		c.metrics.Add(name, 1, map[string]string{
+			"method":    req.Method,
+			"operation": operationLabel(req.Context()),
+		})
+	}
+}
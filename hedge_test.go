@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithHedging(t *testing.T) {
+	tests := []struct {
+		name         string
+		primaryDelay time.Duration
+		method       string
+		wantCalls    int64
+		wantLaunches float64
+		wantWins     float64
+		wantBody     string
+	}{
+		{
+			name:         "slow primary",
+			primaryDelay: time.Second,
+			method:       http.MethodGet,
+			wantCalls:    2,
+			wantLaunches: 1,
+			wantWins:     1,
+			wantBody:     "call 2",
+		},
+		{
+			name:      "fast primary",
+			method:    http.MethodGet,
+			wantCalls: 1,
+			wantBody:  "call 1",
+		},
+		{
+			name:         "not idempotent",
+			primaryDelay: 100 * time.Millisecond,
+			method:       http.MethodPost,
+			wantCalls:    1,
+			wantBody:     "call 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int64
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := calls.Add(1)
+				if n == 1 {
+					select {
+					case <-time.After(tt.primaryDelay):
+					case <-r.Context().Done():
+						return
+					}
+				}
+				fmt.Fprintf(w, "call %d", n)
+			}))
+			defer srv.Close()
+
+			metrics := newRecordingMetrics()
+			c := NewDefaultClient(WithHedging(20*time.Millisecond), WithMetrics(metrics))
+			req, err := http.NewRequestWithContext(context.Background(), tt.method, srv.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := c.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+
+			if string(body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("server calls = %d, want %d", got, tt.wantCalls)
+			}
+			labels := map[string]string{"method": tt.method, "operation": ""}
+			if got := sumValues(metrics.values(MetricHedgeLaunches, labels)); got != tt.wantLaunches {
+				t.Errorf("hedge launches = %v, want %v", got, tt.wantLaunches)
+			}
+			if got := sumValues(metrics.values(MetricHedgeWins, labels)); got != tt.wantWins {
+				t.Errorf("hedge wins = %v, want %v", got, tt.wantWins)
+			}
+		})
+	}
+}
+
+func TestWithHedging_PrimaryWinsAfterHedgeFails(t *testing.T) {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+		io.WriteString(w, "primary")
+	}))
+	defer srv.Close()
+
+	metrics := newRecordingMetrics()
+	c := NewDefaultClient(WithHedging(20*time.Millisecond), WithMetrics(metrics))
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "primary" {
+		t.Errorf("body = %q, want %q", body, "primary")
+	}
+	labels := map[string]string{"method": http.MethodGet, "operation": ""}
+	if got := sumValues(metrics.values(MetricHedgeLaunches, labels)); got != 1 {
+		t.Errorf("hedge launches = %v, want 1", got)
+	}
+	if got := sumValues(metrics.values(MetricHedgeWins, labels)); got != 0 {
+		t.Errorf("hedge wins = %v, want 0", got)
+	}
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// WithPerHostIdleTimeout closes idle HTTP/1 connections to the given hosts
+// after their own timeout instead of the transport's global one. Keys are a
+// host name, matching any port, or a host:port.
+func WithPerHostIdleTimeout(timeouts map[string]time.Duration) Option {
+	return func(c *DefaultClient) {
+		c.hostIdle = make(map[string]time.Duration, len(timeouts))
+		for host, d := range timeouts {
+			c.hostIdle[host] = d
+		}
+		next := c.dial
+		c.dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := next(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			timeout := c.idleTimeout(addr)
+			if timeout <= 0 {
+				return conn, nil
+			}
+			return &idleConn{Conn: conn, timeout: timeout}, nil
+		}
+	}
+}
+
+func (c *DefaultClient) idleTimeout(addr string) time.Duration {
+	if d, ok := c.hostIdle[addr]; ok {
+		return d
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 0
+	}
+	return c.hostIdle[host]
+}
+
+// idleConn closes itself once it has sat in the idle pool for timeout.
+// The timer only closes the connection if it is still idle in the same
+// stretch it was started for, so a connection handed out just as the timer
+// fires is left alone. Closing one the transport has picked but not yet
+// reported through GotConn fails like a server closing an idle
+// connection, which the transport retries for replayable requests.
+type idleConn struct {
+	net.Conn
+	timeout time.Duration
+
+	mu    sync.Mutex
+	timer *time.Timer
+	idle  bool
+	gen   int // incremented each time the connection goes idle
+}
+
+func (c *idleConn) markBusy() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.idle = false
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+}
+
+func (c *idleConn) markIdle() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.idle = true
+	c.gen++
+	gen := c.gen
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.timer = time.AfterFunc(c.timeout, func() { c.expire(gen) })
+}
+
+func (c *idleConn) expire(gen int) {
+	c.mu.Lock()
+	stale := !c.idle || c.gen != gen
+	c.mu.Unlock()
+	if !stale {
+		c.Conn.Close()
+	}
+}
+
+func (c *idleConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+func (c *idleConn) Close() error {
+	c.markBusy()
+	return c.Conn.Close()
+}
+
+// pooledConn is implemented by connections that act on being taken from or
+// returned to the transport's idle pool.
+type pooledConn interface {
+	markBusy()
+	markIdle()
+}
+
+// trackIdle marks req's connection busy while it serves the request and
+// idle once the transport puts it back in the pool.
+func (c *DefaultClient) trackIdle(req *http.Request) *http.Request {
+	if c.hostIdle == nil && c.connLifetime <= 0 {
+		return req
+	}
+	var (
+		mu    sync.Mutex
+		conns []pooledConn
+	)
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			pcs := findPooledConns(info.Conn)
+			for _, pc := range pcs {
+				pc.markBusy()
+			}
+			mu.Lock()
+			conns = pcs
+			mu.Unlock()
+		},
+		PutIdleConn: func(err error) {
+			mu.Lock()
+			pcs := conns
+			mu.Unlock()
+			if err == nil {
+				for _, pc := range pcs {
+					pc.markIdle()
+				}
+			}
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+// findPooledConns unwraps conn, through TLS and other wrappers, collecting
+// the pooledConns along the way.
+func findPooledConns(conn net.Conn) []pooledConn {
+	var pcs []pooledConn
+	for conn != nil {
+		if pc, ok := conn.(pooledConn); ok {
+			pcs = append(pcs, pc)
+		}
+		nc, ok := conn.(interface{ NetConn() net.Conn })
+		if !ok {
+			break
+		}
+		conn = nc.NetConn()
+	}
+	return pcs
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithPerHostIdleTimeout(t *testing.T) {
+	newServer := func(opened, closed *atomic.Int64) *httptest.Server {
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		}))
+		srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			switch state {
+			case http.StateNew:
+				opened.Add(1)
+			case http.StateClosed:
+				closed.Add(1)
+			}
+		}
+		srv.Start()
+		return srv
+	}
+	var shortOpened, shortClosed, longOpened, longClosed atomic.Int64
+	short, long := newServer(&shortOpened, &shortClosed), newServer(&longOpened, &longClosed)
+	defer short.Close()
+	defer long.Close()
+
+	c := NewDefaultClient(WithPerHostIdleTimeout(map[string]time.Duration{
+		strings.TrimPrefix(short.URL, "http://"): 50 * time.Millisecond,
+		strings.TrimPrefix(long.URL, "http://"):  time.Minute,
+	}))
+	for _, url := range []string{short.URL, long.URL} {
+		if _, err := FetchDataFrom(c, url); err != nil {
+			t.Fatalf("FetchDataFrom(%s): %v", url, err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for shortClosed.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if shortClosed.Load() != 1 {
+		t.Fatal("idle connection to the short-timeout host was not closed")
+	}
+	if longClosed.Load() != 0 {
+		t.Error("idle connection to the long-timeout host was closed")
+	}
+
+	// The long-timeout host's connection is still pooled and gets reused.
+	if _, err := FetchDataFrom(c, long.URL); err != nil {
+		t.Fatalf("FetchDataFrom() error = %v", err)
+	}
+	if got := longOpened.Load(); got != 1 {
+		t.Errorf("long-timeout host saw %d connections, want 1", got)
+	}
+}
+
+func TestPerHostIdleTimeoutLookup(t *testing.T) {
+	c := NewDefaultClient(WithPerHostIdleTimeout(map[string]time.Duration{
+		"api.example.com":      time.Second,
+		"api.example.com:8443": 2 * time.Second,
+	}))
+	tests := []struct {
+		addr string
+		want time.Duration
+	}{
+		{addr: "api.example.com:443", want: time.Second},
+		{addr: "api.example.com:8443", want: 2 * time.Second},
+		{addr: "other.example.com:443", want: 0},
+	}
+	for _, tt := range tests {
+		if got := c.idleTimeout(tt.addr); got != tt.want {
+			t.Errorf("idleTimeout(%q) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+type closeCountConn struct {
+	net.Conn
+	closes atomic.Int32
+}
+
+func (c *closeCountConn) Close() error {
+	c.closes.Add(1)
+	return nil
+}
+
+func TestIdleConn_ExpireAfterReuse(t *testing.T) {
+	raw := &closeCountConn{}
+	conn := &idleConn{Conn: raw, timeout: time.Hour}
+
+	conn.markIdle()
+	stale := conn.gen
+	conn.markBusy()
+	conn.expire(stale)
+	if got := raw.closes.Load(); got != 0 {
+		t.Fatalf("busy connection closed %d times, want 0", got)
+	}
+
+	conn.markIdle()
+	conn.expire(stale)
+	if got := raw.closes.Load(); got != 0 {
+		t.Fatalf("connection closed %d times by an earlier idle timer, want 0", got)
+	}
+
+	conn.expire(conn.gen)
+	if got := raw.closes.Load(); got != 1 {
+		t.Errorf("idle connection closed %d times, want 1", got)
+	}
+	conn.markBusy()
+}
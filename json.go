@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Post is a post as served by Endpoint.
+type Post struct {
+	UserID int    `json:"userId"`
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+}
+
+// FetchJSON fetches url and decodes its JSON body into a T.
+func FetchJSON[T any](ctx context.Context, client HTTPClient, url string) (T, error) {
+	v, _, err := fetchJSON[T](ctx, client, url)
+	if err != nil {
+		return v, mapError(client, err)
+	}
+	return v, nil
+}
+
+// FetchJSONRaw fetches url like FetchJSON but also returns the body as read,
+// after any decompression. The body is returned even when it fails to
+// decode.
+func FetchJSONRaw[T any](client HTTPClient, url string) (T, []byte, error) {
+	v, raw, err := fetchJSON[T](context.Background(), client, url)
+	if err != nil {
+		return v, raw, mapError(client, err)
+	}
+	return v, raw, nil
+}
+
+// FetchPosts fetches and decodes the posts at the default endpoint, Endpoint
+// unless overridden with WithDefaultEndpoint.
+func FetchPosts(ctx context.Context, client HTTPClient) ([]Post, error) {
+	return withFallback(ctx, client, func(url string) ([]Post, error) {
+		return FetchJSON[[]Post](ctx, client, url)
+	})
+}
+
+// WithRetryOnDecodeError makes FetchJSON and FetchPosts fetch again when a
+// successful response's body is not valid JSON, e.g. because a proxy
+// corrupted it in transit. Decoding is tried up to the WithRetry attempt
+// limit, waiting its backoff in between.
+func WithRetryOnDecodeError() Option {
+	return func(c *DefaultClient) {
+		c.retryDecodeErrors = true
+	}
+}
+
+// decodeRetrier is implemented by clients that refetch bodies that fail to
+// decode.
+type decodeRetrier interface {
+	// retryDecode waits before another attempt after a decode failure on
+	// the given attempt, reporting whether one should be made.
+	retryDecode(ctx context.Context, attempt int) bool
+}
+
+func (c *DefaultClient) retryDecode(ctx context.Context, attempt int) bool {
+	if !c.retryDecodeErrors || attempt >= c.maxAttempts {
+		return false
+	}
+	return sleep(ctx, c.backoffDelay(attempt)) == nil
+}
+
+func fetchJSON[T any](ctx context.Context, client HTTPClient, url string) (T, []byte, error) {
+	for attempt := 1; ; attempt++ {
+		var v T
+		resp, err := fetch(ctx, client, url)
+		if err != nil {
+			return v, nil, err
+		}
+		err = json.Unmarshal(resp.Body, &v)
+		if err == nil {
+			return v, resp.Body, nil
+		}
+		// Whoever shares this result would get the same corrupt body.
+		if co, ok := client.(coalescer); ok {
+			co.discardFetch(url)
+		}
+		if dr, ok := client.(decodeRetrier); !ok || !dr.retryDecode(ctx, attempt) {
+			return v, resp.Body, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchPosts(t *testing.T) {
+	client := &mockHTTPClient{
+		doFunc: func(url string) (*http.Response, error) {
+			if url != Endpoint {
+				t.Errorf("URL = %s, want %s", url, Endpoint)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`[{"userId":1,"id":2,"title":"t","body":"b"}]`)),
+			}, nil
+		},
+	}
+	posts, err := FetchPosts(context.Background(), client)
+	if err != nil {
+		t.Fatalf("FetchPosts() error = %v", err)
+	}
+	want := []Post{{UserID: 1, ID: 2, Title: "t", Body: "b"}}
+	if !reflect.DeepEqual(posts, want) {
+		t.Errorf("posts = %+v, want %+v", posts, want)
+	}
+}
+
+func TestWithRetryOnDecodeError(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		corrupt   int64 // number of leading corrupt responses
+		wantCalls int64
+		wantErr   bool
+	}{
+		{
+			name:      "retry recovers",
+			opts:      []Option{WithRetry(3, fastBackoff), WithRetryOnDecodeError()},
+			corrupt:   1,
+			wantCalls: 2,
+		},
+		{
+			name:      "disabled",
+			opts:      []Option{WithRetry(3, fastBackoff)},
+			corrupt:   1,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "bounded by retry cap",
+			opts:      []Option{WithRetry(3, fastBackoff), WithRetryOnDecodeError()},
+			corrupt:   5,
+			wantCalls: 3,
+			wantErr:   true,
+		},
+		{
+			name:      "refetched despite coalescing",
+			opts:      []Option{WithRetry(3, fastBackoff), WithRetryOnDecodeError(), WithCoalesceWindow(time.Second)},
+			corrupt:   1,
+			wantCalls: 2,
+		},
+		{
+			name:      "no retry without WithRetry",
+			opts:      []Option{WithRetryOnDecodeError()},
+			corrupt:   1,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int64
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tt.corrupt {
+					io.WriteString(w, `[{"userId":1,"id":1,"ti`)
+					return
+				}
+				io.WriteString(w, `[{"userId":1,"id":1,"title":"ok"}]`)
+			}))
+			defer srv.Close()
+
+			posts, err := FetchJSON[[]Post](context.Background(), NewDefaultClient(tt.opts...), srv.URL)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected a decode error")
+				}
+			} else if err != nil {
+				t.Fatalf("FetchJSON() error = %v", err)
+			} else if len(posts) != 1 || posts[0].Title != "ok" {
+				t.Errorf("posts = %+v, want the valid body", posts)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("server calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestFetchJSONRaw(t *testing.T) {
+	valid := `[{"userId":1,"id":2,"title":"t","body":"b"}]`
+	tests := []struct {
+		name     string
+		body     string
+		gzipped  bool
+		wantPost Post
+		wantErr  bool
+	}{
+		{
+			name:     "decoded and raw",
+			body:     valid,
+			wantPost: Post{UserID: 1, ID: 2, Title: "t", Body: "b"},
+		},
+		{
+			name:     "raw is decompressed",
+			body:     valid,
+			gzipped:  true,
+			wantPost: Post{UserID: 1, ID: 2, Title: "t", Body: "b"},
+		},
+		{
+			name:    "raw kept on decode error",
+			body:    `[{"userId":1,"ti`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.gzipped {
+					w.Header().Set("Content-Encoding", "gzip")
+					w.Write(gzipBytes(t, []byte(tt.body)))
+					return
+				}
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			posts, raw, err := FetchJSONRaw[[]Post](NewDefaultClient(), srv.URL)
+			if string(raw) != tt.body {
+				t.Errorf("raw = %q, want %q", raw, tt.body)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected a decode error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchJSONRaw() error = %v", err)
+			}
+			if len(posts) != 1 || posts[0] != tt.wantPost {
+				t.Errorf("posts = %+v, want [%+v]", posts, tt.wantPost)
+			}
+		})
+	}
+}
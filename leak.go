@@ -0,0 +1,59 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// WithLeakDetection counts the response bodies the transport hands out and
+// how many of them get closed, so OpenBodies can reveal callers that forget
+// to close bodies.
+func WithLeakDetection() Option {
+	return func(c *DefaultClient) {
+		c.leaks = &leakTransport{}
+	}
+}
+
+// OpenBodies returns the number of response bodies opened but not yet
+// closed. A count that keeps growing under steady load indicates a leak. It
+// is always 0 without WithLeakDetection.
+func (c *DefaultClient) OpenBodies() int64 {
+	if c.leaks == nil {
+		return 0
+	}
+	return c.leaks.opened.Load() - c.leaks.closed.Load()
+}
+
+// leakTransport counts opened and closed response bodies.
+type leakTransport struct {
+	next   http.RoundTripper
+	opened atomic.Int64
+	closed atomic.Int64
+}
+
+func (t *leakTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	t.opened.Add(1)
+	resp.Body = &countedBody{rc: resp.Body, closed: &t.closed}
+	return resp, nil
+}
+
+type countedBody struct {
+	rc     io.ReadCloser
+	closed *atomic.Int64
+	once   sync.Once
+}
+
+func (b *countedBody) Read(p []byte) (int, error) {
+	return b.rc.Read(p)
+}
+
+func (b *countedBody) Close() error {
+	b.once.Do(func() { b.closed.Add(1) })
+	return b.rc.Close()
+}
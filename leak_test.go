@@ -0,0 +1,52 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithLeakDetection(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c := NewDefaultClient(WithLeakDetection())
+	for _, path := range []string{"/", "/redirect"} {
+		if _, err := FetchDataFrom(c, srv.URL+path); err != nil {
+			t.Fatalf("FetchDataFrom() error = %v", err)
+		}
+	}
+	if got := c.OpenBodies(); got != 0 {
+		t.Errorf("after proper use OpenBodies = %d, want 0", got)
+	}
+
+	var leaked []*http.Response
+	for range 3 {
+		resp, err := c.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		leaked = append(leaked, resp)
+	}
+	if got := c.OpenBodies(); got != 3 {
+		t.Errorf("with leaked bodies OpenBodies = %d, want 3", got)
+	}
+
+	for _, resp := range leaked {
+		resp.Body.Close()
+		resp.Body.Close()
+	}
+	if got := c.OpenBodies(); got != 0 {
+		t.Errorf("after closing OpenBodies = %d, want 0", got)
+	}
+
+	if got := NewDefaultClient().OpenBodies(); got != 0 {
+		t.Errorf("without leak detection OpenBodies = %d, want 0", got)
+	}
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"container/heap"
+	"context"
+	"net/http"
+	"sync"
+)
+
+// WithMaxConcurrentRequests limits the client to n requests in flight at
+// once, counting each until its response body is read to the end or closed.
+// Requests beyond the limit wait, in order of their WithRequestPriority and
+// then first come, first served, until a slot frees up or their context is
+// done.
+func WithMaxConcurrentRequests(n int) Option {
+	return func(c *DefaultClient) {
+		c.limiter = &limiter{slots: max(n, 1)}
+	}
+}
+
+type priorityKey struct{}
+
+// WithRequestPriority returns a copy of ctx whose requests are admitted
+// ahead of lower-priority ones queued by WithMaxConcurrentRequests. The
+// default priority is 0.
+func WithRequestPriority(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+func requestPriority(ctx context.Context) int {
+	p, _ := ctx.Value(priorityKey{}).(int)
+	return p
+}
+
+type limiter struct {
+	mu      sync.Mutex
+	slots   int
+	inUse   int
+	seq     uint64
+	waiting waitQueue
+}
+
+type waiter struct {
+	priority int
+	seq      uint64
+	index    int
+	ready    chan struct{}
+}
+
+// acquire waits for a slot for req, returning a func that gives it back. It
+// may be called more than once. A nil limiter admits every request.
+func (l *limiter) acquire(req *http.Request) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	l.mu.Lock()
+	if l.inUse < l.slots && len(l.waiting) == 0 {
+		l.inUse++
+		l.mu.Unlock()
+		return l.releaseFunc(), nil
+	}
+	l.seq++
+	w := &waiter{priority: requestPriority(req.Context()), seq: l.seq, ready: make(chan struct{})}
+	heap.Push(&l.waiting, w)
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return l.releaseFunc(), nil
+	case <-req.Context().Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		select {
+		case <-w.ready:
+			// Granted a slot just as the context ended; pass it on.
+			l.handOff()
+		default:
+			heap.Remove(&l.waiting, w.index)
+		}
+		return nil, req.Context().Err()
+	}
+}
+
+func (l *limiter) releaseFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.handOff()
+		})
+	}
+}
+
+// handOff gives a held slot to the first waiter, or frees it. l.mu must be
+// held.
+func (l *limiter) handOff() {
+	if len(l.waiting) == 0 {
+		l.inUse--
+		return
+	}
+	close(heap.Pop(&l.waiting).(*waiter).ready)
+}
+
+// waitQueue orders waiters by descending priority, then arrival.
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() any {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return w
+}
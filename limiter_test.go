@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitLimiter blocks until c's limiter has inUse slots taken and queued
+// requests waiting.
+func waitLimiter(t *testing.T, c *DefaultClient, inUse, queued int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		c.limiter.mu.Lock()
+		gotInUse, gotQueued := c.limiter.inUse, len(c.limiter.waiting)
+		c.limiter.mu.Unlock()
+		if gotInUse == inUse && gotQueued == queued {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d in use and %d queued requests", inUse, queued)
+}
+
+func TestWithRequestPriority(t *testing.T) {
+	unblock := make(chan struct{})
+	var unblockOnce sync.Once
+	release := func() { unblockOnce.Do(func() { close(unblock) }) }
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hold" {
+			<-unblock
+			return
+		}
+		mu.Lock()
+		order = append(order, r.URL.Path)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	defer release()
+
+	c := NewDefaultClient(WithMaxConcurrentRequests(1))
+	fetch := func(path string, priority int) error {
+		ctx := WithRequestPriority(context.Background(), priority)
+		_, err := FetchDataContext(ctx, c, srv.URL+path)
+		return err
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	start := func(path string, priority, queued int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- fetch(path, priority)
+		}()
+		waitLimiter(t, c, 1, queued)
+	}
+	start("/hold", 0, 0)
+	start("/low1", 0, 1)
+	start("/low2", -1, 2)
+	start("/low3", 0, 3)
+	start("/high", 10, 4)
+	release()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("FetchDataContext() error = %v", err)
+		}
+	}
+
+	want := []string{"/high", "/low1", "/low3", "/low2"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("admission order = %v, want %v", order, want)
+	}
+}
+
+func TestWithMaxConcurrentRequests_ContextDone(t *testing.T) {
+	unblock := make(chan struct{})
+	var unblockOnce sync.Once
+	release := func() { unblockOnce.Do(func() { close(unblock) }) }
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hold" {
+			<-unblock
+		}
+	}))
+	defer srv.Close()
+	defer release()
+
+	c := NewDefaultClient(WithMaxConcurrentRequests(1))
+	held := make(chan error, 1)
+	go func() {
+		_, err := FetchDataFrom(c, srv.URL+"/hold")
+		held <- err
+	}()
+	waitLimiter(t, c, 1, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := FetchDataContext(ctx, c, srv.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("queued request err = %v, want context.DeadlineExceeded", err)
+	}
+
+	release()
+	if err := <-held; err != nil {
+		t.Fatalf("FetchDataFrom() held request error = %v", err)
+	}
+	// Neither request leaked its slot.
+	if _, err := FetchDataFrom(c, srv.URL); err != nil {
+		t.Fatalf("FetchDataFrom() follow-up request error = %v", err)
+	}
+	if c.limiter.inUse != 0 {
+		t.Errorf("slots in use = %d, want 0", c.limiter.inUse)
+	}
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrResponseTooLarge is returned when a response body exceeds the limit set
+// with WithMaxResponseBytes.
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// WithMaxResponseBytes caps the number of body bytes read from a response.
+// The cap applies to the decompressed stream, so a small compressed payload
+// that inflates past n is rejected with ErrResponseTooLarge.
+func WithMaxResponseBytes(n int64) Option {
+	return func(c *DefaultClient) {
+		c.maxResponseBytes = n
+	}
+}
+
+type limitedBody struct {
+	rc   io.ReadCloser
+	max  int64
+	read int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.read > b.max {
+		return 0, ErrResponseTooLarge
+	}
+	if room := b.max - b.read + 1; int64(len(p)) > room {
+		p = p[:room]
+	}
+	n, err := b.rc.Read(p)
+	b.read += int64(n)
+	if b.read > b.max {
+		return n - int(b.read-b.max), ErrResponseTooLarge
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.rc.Close()
+}
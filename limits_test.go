@@ -0,0 +1,113 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWithMaxResponseBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		max     int64
+		wantErr bool
+	}{
+		{
+			name:    "body under limit",
+			body:    `[{"id":1}]`,
+			max:     64,
+			wantErr: false,
+		},
+		{
+			name:    "body exactly at limit",
+			body:    strings.Repeat("a", 64),
+			max:     64,
+			wantErr: false,
+		},
+		{
+			name:    "body over limit",
+			body:    strings.Repeat("a", 65),
+			max:     64,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(WithMaxResponseBytes(tt.max))
+			resp, err := c.Get(srv.URL)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			got, err := io.ReadAll(resp.Body)
+			if tt.wantErr {
+				if !errors.Is(err, ErrResponseTooLarge) {
+					t.Errorf("ReadAll() error = %v, want ErrResponseTooLarge", err)
+				}
+				if int64(len(got)) > tt.max {
+					t.Errorf("read %d bytes, want at most %d", len(got), tt.max)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadAll() unexpected error = %v", err)
+			}
+			if string(got) != tt.body {
+				t.Errorf("ReadAll() = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestWithMaxResponseBytes_DecompressionBomb(t *testing.T) {
+	payload := gzipBytes(t, make([]byte, 10<<20))
+	const max = 1 << 20
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(payload)
+	}))
+	defer srv.Close()
+
+	if len(payload) >= max {
+		t.Fatalf("compressed payload is %d bytes, want it under the %d byte limit", len(payload), max)
+	}
+
+	c := NewDefaultClient(WithMaxResponseBytes(max))
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	_, err = io.ReadAll(resp.Body)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("ReadAll() error = %v, want ErrResponseTooLarge", err)
+	}
+}
@@ -0,0 +1,22 @@
+package client
+
+import "log/slog"
+
+// WithLogger sets the logger for the client's log output, such as body
+// samples. By default slog.Default() is used.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *DefaultClient) {
+		c.logger = logger
+	}
+}
+
+func (c *DefaultClient) log() *slog.Logger {
+	logger := c.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if c.name != "" {
+		logger = logger.With(slog.String("client", c.name))
+	}
+	return logger
+}
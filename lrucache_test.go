@@ -0,0 +1,108 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLRUCache_EntryCap(t *testing.T) {
+	c := NewLRUCache(2, 0)
+
+	c.Set("a", []byte("1"), time.Minute)
+	c.Set("b", []byte("2"), time.Minute)
+	c.Set("c", []byte("3"), time.Minute)
+
+	if _, ok := c.Get("a"); ok {
+		t.Error("Get(a) hit, want oldest entry evicted")
+	}
+	for _, key := range []string{"b", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("Get(%s) miss, want hit", key)
+		}
+	}
+}
+
+func TestLRUCache_ByteCap(t *testing.T) {
+	c := NewLRUCache(0, 10)
+
+	c.Set("a", []byte(strings.Repeat("a", 4)), time.Minute)
+	c.Set("b", []byte(strings.Repeat("b", 4)), time.Minute)
+	c.Set("c", []byte(strings.Repeat("c", 4)), time.Minute)
+
+	if _, ok := c.Get("a"); ok {
+		t.Error("Get(a) hit, want evicted to stay under byte cap")
+	}
+	if got := c.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+
+	c.Set("huge", []byte(strings.Repeat("x", 11)), time.Minute)
+	if _, ok := c.Get("huge"); ok {
+		t.Error("Get(huge) hit, want values larger than the byte cap rejected")
+	}
+}
+
+func TestLRUCache_GetRefreshesRecency(t *testing.T) {
+	c := NewLRUCache(2, 0)
+
+	c.Set("a", []byte("1"), time.Minute)
+	c.Set("b", []byte("2"), time.Minute)
+	c.Get("a")
+	c.Set("c", []byte("3"), time.Minute)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("Get(b) hit, want least recently used entry evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("Get(a) miss, want recently read entry kept")
+	}
+}
+
+func TestLRUCache_ReplaceAndExpiry(t *testing.T) {
+	now := time.Now()
+	c := NewLRUCache(0, 8)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", []byte("1234"), time.Second)
+	c.Set("a", []byte("5678"), time.Second)
+	if got, _ := c.Get("a"); string(got) != "5678" {
+		t.Errorf("Get(a) = %q, want 5678", got)
+	}
+	c.Set("b", []byte("abcd"), time.Hour)
+	if got := c.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2 (replacing must not double count bytes)", got)
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := c.Get("a"); ok {
+		t.Error("Get(a) hit after expiry, want miss")
+	}
+	if got := c.Len(); got != 1 {
+		t.Errorf("Len() = %d, want 1", got)
+	}
+}
+
+func TestLRUCache_Concurrent(t *testing.T) {
+	c := NewLRUCache(8, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := fmt.Sprintf("key-%d", (i+j)%12)
+				c.Set(key, []byte(key), time.Minute)
+				c.Get(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := c.Len(); got > 8 {
+		t.Errorf("Len() = %d, want at most 8", got)
+	}
+}
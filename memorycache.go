@@ -0,0 +1,74 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryCache is an in-memory Cache. Expired entries are evicted when read
+// or when Prune is called.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+}
+
+type memoryEntry struct {
+	val     []byte
+	expires time.Time
+}
+
+// NewMemoryCache returns an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		entries: make(map[string]memoryEntry),
+		now:     time.Now,
+	}
+}
+
+func (m *MemoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !m.now().Before(e.expires) {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return e.val, true
+}
+
+func (m *MemoryCache) Set(key string, val []byte, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = memoryEntry{val: val, expires: m.now().Add(ttl)}
+}
+
+func (m *MemoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+}
+
+// Prune removes all expired entries.
+func (m *MemoryCache) Prune() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for key, e := range m.entries {
+		if !now.Before(e.expires) {
+			delete(m.entries, key)
+		}
+	}
+}
+
+// Len returns the number of stored entries, including expired ones not yet
+// pruned.
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryCache(t *testing.T) {
+	now := time.Now()
+	c := NewMemoryCache()
+	c.now = func() time.Time { return now }
+
+	if _, ok := c.Get("missing"); ok {
+		t.Error("Get(missing) hit, want miss")
+	}
+
+	c.Set("key", []byte("value"), time.Minute)
+	if got, ok := c.Get("key"); !ok || string(got) != "value" {
+		t.Errorf("Get(key) = %q, %v, want value, true", got, ok)
+	}
+
+	c.Delete("key")
+	if _, ok := c.Get("key"); ok {
+		t.Error("Get(key) hit after Delete, want miss")
+	}
+}
+
+func TestMemoryCache_Expiry(t *testing.T) {
+	now := time.Now()
+	c := NewMemoryCache()
+	c.now = func() time.Time { return now }
+
+	c.Set("short", []byte("a"), time.Second)
+	c.Set("long", []byte("b"), time.Hour)
+
+	now = now.Add(time.Minute)
+	if _, ok := c.Get("short"); ok {
+		t.Error("Get(short) hit after expiry, want miss")
+	}
+	if _, ok := c.Get("long"); !ok {
+		t.Error("Get(long) miss, want hit")
+	}
+}
+
+func TestMemoryCache_Prune(t *testing.T) {
+	now := time.Now()
+	c := NewMemoryCache()
+	c.now = func() time.Time { return now }
+
+	c.Set("a", []byte("a"), time.Second)
+	c.Set("b", []byte("b"), time.Second)
+	c.Set("c", []byte("c"), time.Hour)
+
+	now = now.Add(time.Minute)
+	c.Prune()
+
+	if got := c.Len(); got != 1 {
+		t.Errorf("Len() after Prune = %d, want 1", got)
+	}
+}
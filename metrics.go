@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metric names reported through Metrics.
+const (
+	MetricRequestDuration  = "http_client_request_duration_seconds"
+	MetricCompressionRatio = "http_client_response_compression_ratio"
+	MetricStreamRecords    = "http_client_stream_records_total"
+)
+
+// Metrics receives client instrumentation. Implementations typically
+// forward to Prometheus histograms and counters, using the label names as
+// the metric's label set.
+type Metrics interface {
+	Observe(name string, value float64, labels map[string]string)
+	Add(name string, delta float64, labels map[string]string)
+}
+
+// WithMetrics reports request metrics to m. Every request records
+// MetricRequestDuration labelled with method, status and operation, and
+// every StreamSSE or FetchNDJSON stream adds the records it delivered to
+// MetricStreamRecords, labelled with format ("sse" or "ndjson") and
+// operation.
+func WithMetrics(m Metrics) Option {
+	return func(c *DefaultClient) {
+		c.metrics = m
+	}
+}
+
+// WithClientName names the client, telling apart the DefaultClients of an
+// application with several: every metric gets a "client" label and every
+// log record a "client" attribute holding name. Unnamed clients add
+// neither.
+func WithClientName(name string) Option {
+	return func(c *DefaultClient) {
+		c.name = name
+	}
+}
+
+// namedMetrics adds the client label to every metric.
+type namedMetrics struct {
+	Metrics
+	name string
+}
+
+func (m namedMetrics) Observe(name string, value float64, labels map[string]string) {
+	m.Metrics.Observe(name, value, m.label(labels))
+}
+
+func (m namedMetrics) Add(name string, delta float64, labels map[string]string) {
+	m.Metrics.Add(name, delta, m.label(labels))
+}
+
+func (m namedMetrics) label(labels map[string]string) map[string]string {
+	out := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		out[k] = v
+	}
+	out["client"] = m.name
+	return out
+}
+
+// WithLatencyCallback calls fn after every request with its URL, status
+// code and duration until the response headers arrived. Requests that fail
+// without a response report status 0.
+func WithLatencyCallback(fn func(url string, status int, d time.Duration)) Option {
+	return func(c *DefaultClient) {
+		c.latencyCallback = fn
+	}
+}
+
+type operationKey struct{}
+
+// WithOperationLabel returns a copy of ctx whose requests are labelled with
+// the logical operation name in metrics, instead of a high-cardinality URL.
+func WithOperationLabel(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, operationKey{}, name)
+}
+
+func operationLabel(ctx context.Context) string {
+	if name, ok := ctx.Value(operationKey{}).(string); ok {
+		return name
+	}
+	return ""
+}
+
+func (c *DefaultClient) recordRequest(req *http.Request, resp *http.Response, start time.Time) {
+	elapsed := time.Since(start)
+	if c.latencyCallback != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		c.latencyCallback(req.URL.String(), status, elapsed)
+	}
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.Observe(MetricRequestDuration, elapsed.Seconds(), metricLabels(req, resp))
+}
+
+// metricLabels returns the method, status and operation labels for a request.
+func metricLabels(req *http.Request, resp *http.Response) map[string]string {
+	status := "error"
+	if resp != nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	return map[string]string{
+		"method":    req.Method,
+		"status":    status,
+		"operation": operationLabel(req.Context()),
+	}
+}
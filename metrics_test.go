@@ -0,0 +1,222 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingMetrics stores every observation keyed by metric name and sorted
+// label pairs, mirroring how a Prometheus vector splits series.
+type recordingMetrics struct {
+	mu     sync.Mutex
+	series map[string][]float64
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{series: make(map[string][]float64)}
+}
+
+func seriesKey(name string, labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+func (m *recordingMetrics) Observe(name string, value float64, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := seriesKey(name, labels)
+	m.series[key] = append(m.series[key], value)
+}
+
+func (m *recordingMetrics) Add(name string, delta float64, labels map[string]string) {
+	m.Observe(name, delta, labels)
+}
+
+func (m *recordingMetrics) values(name string, labels map[string]string) []float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.series[seriesKey(name, labels)]
+}
+
+func TestWithOperationLabel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	metrics := newRecordingMetrics()
+	c := NewDefaultClient(WithMetrics(metrics))
+
+	for _, op := range []string{"list_posts", "get_user", "list_posts"} {
+		ctx := WithOperationLabel(context.Background(), op)
+		if _, err := FetchDataContext(ctx, c, srv.URL); err != nil {
+			t.Fatalf("FetchDataContext() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		operation string
+		wantCount int
+	}{
+		{operation: "list_posts", wantCount: 2},
+		{operation: "get_user", wantCount: 1},
+	}
+	for _, tt := range tests {
+		got := metrics.values(MetricRequestDuration, map[string]string{
+			"method":    http.MethodGet,
+			"status":    "200",
+			"operation": tt.operation,
+		})
+		if len(got) != tt.wantCount {
+			t.Errorf("operation %q observations = %d, want %d", tt.operation, len(got), tt.wantCount)
+		}
+	}
+	if len(metrics.series) != 2 {
+		t.Errorf("series = %v, want 2 separately labelled series", metrics.series)
+	}
+}
+
+func TestWithMetrics_TransportError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	metrics := newRecordingMetrics()
+	c := NewDefaultClient(WithMetrics(metrics))
+	if _, err := FetchDataFrom(c, url); err == nil {
+		t.Fatal("FetchDataFrom() expected error")
+	}
+
+	got := metrics.values(MetricRequestDuration, map[string]string{
+		"method":    http.MethodGet,
+		"status":    "error",
+		"operation": "",
+	})
+	if len(got) != 1 {
+		t.Errorf("error observations = %d, want 1", len(got))
+	}
+}
+
+func TestWithLatencyCallback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name       string
+		url        string
+		opts       []Option
+		wantStatus int
+	}{
+		{
+			name:       "success",
+			url:        srv.URL + "/posts",
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "transport error",
+			url:        closed.URL + "/posts",
+			wantStatus: 0,
+		},
+		{
+			name: "request editor error",
+			url:  srv.URL + "/posts",
+			opts: []Option{WithRequestEditors(func(context.Context, *http.Request) error {
+				return errors.New("edit failed")
+			})},
+			wantStatus: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			type call struct {
+				url    string
+				status int
+				d      time.Duration
+			}
+			var calls []call
+			c := NewDefaultClient(append(tt.opts, WithLatencyCallback(func(url string, status int, d time.Duration) {
+				calls = append(calls, call{url, status, d})
+			}))...)
+
+			if resp, err := c.Get(tt.url); err == nil {
+				resp.Body.Close()
+			}
+
+			if len(calls) != 1 {
+				t.Fatalf("callback fired %d times, want 1", len(calls))
+			}
+			got := calls[0]
+			if got.url != tt.url || got.status != tt.wantStatus {
+				t.Errorf("callback(%q, %d), want (%q, %d)", got.url, got.status, tt.url, tt.wantStatus)
+			}
+			if got.d <= 0 {
+				t.Errorf("duration = %v, want > 0", got.d)
+			}
+		})
+	}
+}
+
+func TestWithClientName(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		clientName string
+		wantLabels map[string]string
+		wantLog    string
+	}{
+		{
+			name:       "named",
+			clientName: "billing",
+			wantLabels: map[string]string{"method": "GET", "status": "200", "operation": "", "client": "billing"},
+			wantLog:    "client=billing",
+		},
+		{
+			name:       "unnamed",
+			wantLabels: map[string]string{"method": "GET", "status": "200", "operation": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newRecordingMetrics()
+			var out bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			c := NewDefaultClient(WithClientName(tt.clientName), WithMetrics(m), WithLogger(logger), WithBodySampleRate(1, 16))
+			if _, err := FetchDataFrom(c, srv.URL); err != nil {
+				t.Fatalf("FetchDataFrom() error = %v", err)
+			}
+
+			if got := m.values(MetricRequestDuration, tt.wantLabels); len(got) != 1 {
+				t.Errorf("no %s series with labels %v", MetricRequestDuration, tt.wantLabels)
+			}
+			if !strings.Contains(out.String(), "response body sample") {
+				t.Fatalf("log = %q, want a body sample", out.String())
+			}
+			if hasName := strings.Contains(out.String(), "client="); hasName != (tt.wantLog != "") || !strings.Contains(out.String(), tt.wantLog) {
+				t.Errorf("log = %q, want client attribute %q", out.String(), tt.wantLog)
+			}
+		})
+	}
+}
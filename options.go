@@ -0,0 +1,28 @@
+package client
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+)
+
+// Option configures a DefaultClient.
+type Option func(*DefaultClient)
+
+// ErrInvalidOption is returned by every request made with a client that was
+// given an invalid option, e.g. a malformed URL read from config.
+var ErrInvalidOption = errors.New("invalid client option")
+
+// invalidOption records err so that requests fail with it instead of the
+// client panicking while it is configured.
+func (c *DefaultClient) invalidOption(err error) {
+	c.optionErr = errors.Join(c.optionErr, fmt.Errorf("%w: %w", ErrInvalidOption, err))
+}
+
+// tlsConfig returns the transport's TLS config, creating it on first use.
+func (c *DefaultClient) tlsConfig() *tls.Config {
+	if c.transport.TLSClientConfig == nil {
+		c.transport.TLSClientConfig = &tls.Config{}
+	}
+	return c.transport.TLSClientConfig
+}
@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrPaginationLoop is returned when a next link points back at a page
+// that was already fetched.
+var ErrPaginationLoop = errors.New("pagination loop detected")
+
+// WithPageParam makes FetchAllJSON page through APIs that send no Link
+// header by incrementing the named query parameter, starting from its value
+// in the start URL or 1 if it has none. Paging stops at the first empty
+// page. A rel="next" link still takes precedence when a page has one.
+func WithPageParam(name string) Option {
+	return func(c *DefaultClient) {
+		c.pageParam = name
+	}
+}
+
+// pageParamer is implemented by clients with a page query parameter.
+type pageParamer interface {
+	pageQueryParam() string
+}
+
+func (c *DefaultClient) pageQueryParam() string {
+	return c.pageParam
+}
+
+// FetchAllJSON fetches startURL and every following page, decoding each
+// page's JSON array and concatenating the elements in order. The next page
+// is the one linked from the Link header with rel="next" or, with
+// WithPageParam, the next page number; it stops at the first page without
+// one.
+func FetchAllJSON[T any](ctx context.Context, client HTTPClient, startURL string) ([]T, error) {
+	all, err := fetchAllJSON[T](ctx, client, startURL)
+	if err != nil {
+		return nil, mapError(client, err)
+	}
+	return all, nil
+}
+
+func fetchAllJSON[T any](ctx context.Context, client HTTPClient, startURL string) ([]T, error) {
+	var param string
+	if p, ok := client.(pageParamer); ok {
+		param = p.pageQueryParam()
+	}
+	var all []T
+	seen := make(map[string]bool)
+	for url := startURL; url != ""; {
+		if seen[url] {
+			return nil, fmt.Errorf("%w: %s", ErrPaginationLoop, url)
+		}
+		seen[url] = true
+
+		resp, err := fetch(ctx, client, url)
+		if err != nil {
+			return nil, err
+		}
+		var page []T
+		if err := json.Unmarshal(resp.Body, &page); err != nil {
+			return nil, fmt.Errorf("failed to decode page %s: %w", url, err)
+		}
+		all = append(all, page...)
+
+		next := nextLink(resp.Header)
+		if next == "" && param != "" && len(page) > 0 {
+			next = nextPage(resp.FinalURL, param)
+		}
+		if next == "" {
+			break
+		}
+		if url, err = resolveLocation(resp.FinalURL, next); err != nil {
+			return nil, fmt.Errorf("invalid next link: %w", err)
+		}
+	}
+	return all, nil
+}
+
+// Page is one page of a paginated collection. Total, Page and PerPage come
+// from the X-Total-Count, X-Page and X-Per-Page response headers and are
+// zero when the server does not send them.
+type Page[T any] struct {
+	Items   []T
+	Total   int
+	Page    int
+	PerPage int
+}
+
+// FetchPaged fetches url, decoding its JSON array body into Items and its
+// pagination headers into the other fields of a Page.
+func FetchPaged[T any](ctx context.Context, client HTTPClient, url string) (Page[T], error) {
+	resp, err := fetch(ctx, client, url)
+	if err != nil {
+		return Page[T]{}, mapError(client, err)
+	}
+	var items []T
+	if err := json.Unmarshal(resp.Body, &items); err != nil {
+		return Page[T]{}, mapError(client, fmt.Errorf("failed to decode page %s: %w", url, err))
+	}
+	return Page[T]{
+		Items:   items,
+		Total:   headerInt(resp.Header, "X-Total-Count"),
+		Page:    headerInt(resp.Header, "X-Page"),
+		PerPage: headerInt(resp.Header, "X-Per-Page"),
+	}, nil
+}
+
+// headerInt returns the integer value of header name, or 0 if it is missing
+// or malformed.
+func headerInt(header http.Header, name string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(header.Get(name)))
+	return n
+}
+
+// nextPage returns rawURL with its page query parameter incremented, or ""
+// if rawURL cannot be parsed.
+func nextPage(rawURL, param string) string {
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	q := u.Query()
+	page, err := strconv.Atoi(q.Get(param))
+	if err != nil {
+		page = 1
+	}
+	q.Set(param, strconv.Itoa(page+1))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// nextLink returns the target of the rel="next" entry in the Link header.
+func nextLink(header http.Header) string {
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			target, params, ok := strings.Cut(link, ";")
+			target = strings.TrimSpace(target)
+			if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range strings.Split(params, ";") {
+				name, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(name, "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(val, `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+type pagedPost struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func TestFetchAllJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 3 {
+			w.Header().Add("Link", fmt.Sprintf(`</posts?page=%d>; rel="next", </posts?page=3>; rel="last"`, page+1))
+		}
+		posts := []pagedPost{{ID: page*2 - 1}, {ID: page * 2}}
+		json.NewEncoder(w).Encode(posts)
+	}))
+	defer srv.Close()
+
+	got, err := FetchAllJSON[pagedPost](context.Background(), NewDefaultClient(), srv.URL+"/posts?page=1")
+	if err != nil {
+		t.Fatalf("FetchAllJSON() error = %v", err)
+	}
+	if len(got) != 6 {
+		t.Fatalf("len = %d, want 6", len(got))
+	}
+	for i, p := range got {
+		if p.ID != i+1 {
+			t.Errorf("got[%d].ID = %d, want %d", i, p.ID, i+1)
+		}
+	}
+}
+
+func TestFetchAllJSON_PageParam(t *testing.T) {
+	tests := []struct {
+		name     string
+		startURL string
+		wantIDs  []int
+	}{
+		{
+			name:     "from first page",
+			startURL: "/posts?page=1",
+			wantIDs:  []int{1, 2, 3, 4, 5, 6},
+		},
+		{
+			name:     "without page parameter",
+			startURL: "/posts",
+			wantIDs:  []int{1, 2, 3, 4, 5, 6},
+		},
+		{
+			name:     "from a later page",
+			startURL: "/posts?page=2&sort=id",
+			wantIDs:  []int{3, 4, 5, 6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				page, err := strconv.Atoi(r.URL.Query().Get("page"))
+				if err != nil {
+					page = 1
+				}
+				posts := []pagedPost{}
+				if page <= 3 {
+					posts = append(posts, pagedPost{ID: page*2 - 1}, pagedPost{ID: page * 2})
+				}
+				json.NewEncoder(w).Encode(posts)
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(WithPageParam("page"))
+			got, err := FetchAllJSON[pagedPost](context.Background(), c, srv.URL+tt.startURL)
+			if err != nil {
+				t.Fatalf("FetchAllJSON() error = %v", err)
+			}
+			var ids []int
+			for _, p := range got {
+				ids = append(ids, p.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("FetchAllJSON() IDs = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestFetchAllJSON_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		cancel  bool
+		wantErr error
+	}{
+		{
+			name: "next link loops",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Link", `</posts>; rel="next"`)
+				w.Write([]byte(`[]`))
+			},
+			wantErr: ErrPaginationLoop,
+		},
+		{
+			name: "context cancelled",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`[]`))
+			},
+			cancel:  true,
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.cancel {
+				cancel()
+			}
+			defer cancel()
+
+			_, err := FetchAllJSON[pagedPost](ctx, NewDefaultClient(), srv.URL+"/posts")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("FetchAllJSON() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFetchPaged(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    Page[pagedPost]
+	}{
+		{
+			name:    "headers parsed",
+			headers: map[string]string{"X-Total-Count": "42", "X-Page": "3", "X-Per-Page": "2"},
+			want:    Page[pagedPost]{Total: 42, Page: 3, PerPage: 2},
+		},
+		{
+			name: "missing headers are zero",
+		},
+		{
+			name:    "malformed header is zero",
+			headers: map[string]string{"X-Total-Count": "many", "X-Page": "1"},
+			want:    Page[pagedPost]{Page: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.headers {
+					w.Header().Set(k, v)
+				}
+				w.Write([]byte(`[{"id":1,"title":"a"},{"id":2,"title":"b"}]`))
+			}))
+			defer srv.Close()
+
+			got, err := FetchPaged[pagedPost](context.Background(), NewDefaultClient(), srv.URL)
+			if err != nil {
+				t.Fatalf("FetchPaged() error = %v", err)
+			}
+			wantItems := []pagedPost{{ID: 1, Title: "a"}, {ID: 2, Title: "b"}}
+			if len(got.Items) != len(wantItems) || got.Items[0] != wantItems[0] || got.Items[1] != wantItems[1] {
+				t.Errorf("Items = %+v, want %+v", got.Items, wantItems)
+			}
+			if got.Total != tt.want.Total || got.Page != tt.want.Page || got.PerPage != tt.want.PerPage {
+				t.Errorf("Total, Page, PerPage = %d, %d, %d, want %d, %d, %d",
+					got.Total, got.Page, got.PerPage, tt.want.Total, tt.want.Page, tt.want.PerPage)
+			}
+		})
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{link: `<https://api.example.com/posts?page=2>; rel="next"`, want: "https://api.example.com/posts?page=2"},
+		{link: `</posts?page=1>; rel="prev", </posts?page=3>; rel="next"`, want: "/posts?page=3"},
+		{link: `</posts?page=3>; rel="next last"`, want: "/posts?page=3"},
+		{link: `</posts?page=1>; rel="first"`, want: ""},
+		{link: ``, want: ""},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.link != "" {
+			header.Set("Link", tt.link)
+		}
+		if got := nextLink(header); got != tt.want {
+			t.Errorf("nextLink(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+)
+
+// Connection pool gauges reported through Metrics with Add.
+const (
+	MetricOpenConnections   = "http_client_open_connections"
+	MetricActiveConnections = "http_client_active_connections"
+	MetricConnectionWaits   = "http_client_connection_waits"
+)
+
+// PoolStats is a snapshot of the client's connection pool.
+type PoolStats struct {
+	// Open is the number of connections dialed and not yet closed.
+	Open int64
+	// Active is the number of connections serving a request, until its
+	// response body is read to the end or closed.
+	Active int64
+	// Idle is the number of open connections not serving a request.
+	Idle int64
+	// Waiting is the number of requests waiting for a connection.
+	Waiting int64
+}
+
+// WithConnectionPoolMetrics tracks open, active and waiting connections,
+// reporting them as gauges through WithMetrics and via PoolStats. Open
+// connections are counted by wrapping the dialer.
+func WithConnectionPoolMetrics() Option {
+	return func(c *DefaultClient) {
+		c.pool = &pool{}
+		next := c.dial
+		c.dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := next(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			c.poolAdd(&c.pool.open, MetricOpenConnections, 1)
+			return &countedConn{Conn: conn, onClose: func() {
+				c.poolAdd(&c.pool.open, MetricOpenConnections, -1)
+			}}, nil
+		}
+	}
+}
+
+// PoolStats returns the current connection pool counts. They are all zero
+// unless WithConnectionPoolMetrics is set.
+func (c *DefaultClient) PoolStats() PoolStats {
+	if c.pool == nil {
+		return PoolStats{}
+	}
+	s := PoolStats{
+		Open:    c.pool.open.Load(),
+		Active:  c.pool.active.Load(),
+		Waiting: c.pool.waiting.Load(),
+	}
+	s.Idle = max(s.Open-s.Active, 0)
+	return s
+}
+
+type pool struct {
+	open, active, waiting atomic.Int64
+}
+
+func (c *DefaultClient) poolAdd(n *atomic.Int64, name string, delta int64) {
+	n.Add(delta)
+	if c.metrics != nil {
+		c.metrics.Add(name, float64(delta), nil)
+	}
+}
+
+type countedConn struct {
+	net.Conn
+	once    sync.Once
+	onClose func()
+}
+
+// NetConn returns the wrapped connection.
+func (c *countedConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(c.onClose)
+	return c.Conn.Close()
+}
+
+// trackPool follows the connection use of a single try of req. The
+// returned release must be called once the try is over.
+func (c *DefaultClient) trackPool(req *http.Request) (*http.Request, func()) {
+	if c.pool == nil {
+		return req, func() {}
+	}
+
+	var (
+		mu               sync.Mutex
+		waiting, holding bool
+	)
+	// drop ends the current wait and hand back any held connection.
+	drop := func() {
+		if waiting {
+			waiting = false
+			c.poolAdd(&c.pool.waiting, MetricConnectionWaits, -1)
+		}
+		if holding {
+			holding = false
+			c.poolAdd(&c.pool.active, MetricActiveConnections, -1)
+		}
+	}
+	trace := &httptrace.ClientTrace{
+		// Each redirect hop asks for a connection again, after the
+		// previous response has been discarded.
+		GetConn: func(string) {
+			mu.Lock()
+			defer mu.Unlock()
+			drop()
+			waiting = true
+			c.poolAdd(&c.pool.waiting, MetricConnectionWaits, 1)
+		},
+		GotConn: func(httptrace.GotConnInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			drop()
+			holding = true
+			c.poolAdd(&c.pool.active, MetricActiveConnections, 1)
+		},
+	}
+	release := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		drop()
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), release
+}
+
+// releaseBody calls release when the body is read to the end or closed.
+type releaseBody struct {
+	rc      io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	if err == io.EOF {
+		b.release()
+	}
+	return n, err
+}
+
+func (b *releaseBody) Close() error {
+	b.release()
+	return b.rc.Close()
+}
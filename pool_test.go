@@ -0,0 +1,126 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func sumValues(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func TestWithConnectionPoolMetrics(t *testing.T) {
+	const inFlight = 3
+	arrived := make(chan struct{}, inFlight)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	metrics := newRecordingMetrics()
+	c := NewDefaultClient(WithMetrics(metrics), WithConnectionPoolMetrics())
+	gauge := func(name string) float64 { return sumValues(metrics.values(name, nil)) }
+
+	var wg sync.WaitGroup
+	for i := 0; i < inFlight; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := FetchDataFrom(c, srv.URL); err != nil {
+				t.Errorf("FetchDataFrom() error = %v", err)
+			}
+		}()
+	}
+	for i := 0; i < inFlight; i++ {
+		<-arrived
+	}
+
+	if got := c.PoolStats(); got.Active != inFlight || got.Open != inFlight || got.Idle != 0 {
+		t.Errorf("PoolStats() during requests = %+v, want %d active and open", got, inFlight)
+	}
+	if got := gauge(MetricActiveConnections); got != inFlight {
+		t.Errorf("active connections gauge = %v, want %d", got, inFlight)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if got := c.PoolStats(); got.Active != 0 || got.Waiting != 0 || got.Idle != got.Open {
+		t.Errorf("PoolStats() after requests = %+v, want no active or waiting", got)
+	}
+	if got := gauge(MetricActiveConnections); got != 0 {
+		t.Errorf("active connections gauge = %v, want 0", got)
+	}
+
+	c.transport.CloseIdleConnections()
+	deadline := time.Now().Add(time.Second)
+	for c.PoolStats().Open != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := gauge(MetricOpenConnections); got != 0 {
+		t.Errorf("open connections gauge = %v after closing idle connections, want 0", got)
+	}
+}
+
+func TestWithConnectionPoolMetrics_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	closed := srv.URL
+	srv.Close()
+
+	c := NewDefaultClient(WithConnectionPoolMetrics(), WithRetry(2, fastBackoff))
+	if _, err := FetchDataFrom(c, closed); err == nil {
+		t.Fatal("FetchDataFrom() error = nil, want dial error")
+	}
+	if got := c.PoolStats(); got != (PoolStats{}) {
+		t.Errorf("PoolStats() after failed dials = %+v, want zero", got)
+	}
+}
+
+func TestWithConnectionPoolMetrics_Hedging(t *testing.T) {
+	arrived := make(chan struct{}, 2)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	c := NewDefaultClient(WithConnectionPoolMetrics(), WithHedging(10*time.Millisecond))
+	done := make(chan error)
+	go func() {
+		_, err := FetchDataFrom(c, srv.URL)
+		done <- err
+	}()
+	<-arrived
+	<-arrived
+
+	if got := c.PoolStats(); got.Active != 2 {
+		t.Errorf("PoolStats() during hedged request = %+v, want 2 active", got)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("FetchDataFrom() error = %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for c.PoolStats().Active != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := c.PoolStats(); got.Active != 0 || got.Waiting != 0 {
+		t.Errorf("PoolStats() after hedged request = %+v, want no active or waiting", got)
+	}
+}
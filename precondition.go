@@ -0,0 +1,32 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrPreconditionFailed is returned by Execute when the server answers with
+// 412 Precondition Failed, typically because the resource changed since its
+// ETag or modification time was read. The error also wraps the status
+// error, normally an *HTTPError.
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// WithIfMatch returns a RequestEditorFn making the request apply only if the
+// resource's current ETag is etag.
+func WithIfMatch(etag string) RequestEditorFn {
+	return func(_ context.Context, req *http.Request) error {
+		req.Header.Set("If-Match", etag)
+		return nil
+	}
+}
+
+// WithIfUnmodifiedSince returns a RequestEditorFn making the request apply
+// only if the resource has not changed since t.
+func WithIfUnmodifiedSince(t time.Time) RequestEditorFn {
+	return func(_ context.Context, req *http.Request) error {
+		req.Header.Set("If-Unmodified-Since", t.UTC().Format(http.TimeFormat))
+		return nil
+	}
+}
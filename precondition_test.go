@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecute_Preconditions(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m := r.Header.Get("If-Match"); m != "" && m != `"v2"` {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if s := r.Header.Get("If-Unmodified-Since"); s != "" {
+			since, err := http.ParseTime(s)
+			if err != nil || since.Before(modified) {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		editors  []RequestEditorFn
+		wantFail bool
+	}{
+		{
+			name:    "matching etag",
+			editors: []RequestEditorFn{WithIfMatch(`"v2"`)},
+		},
+		{
+			name:     "stale etag",
+			editors:  []RequestEditorFn{WithIfMatch(`"v1"`)},
+			wantFail: true,
+		},
+		{
+			name:    "unmodified",
+			editors: []RequestEditorFn{WithIfUnmodifiedSince(modified)},
+		},
+		{
+			name:     "modified since",
+			editors:  []RequestEditorFn{WithIfUnmodifiedSince(modified.Add(-time.Hour))},
+			wantFail: true,
+		},
+		{
+			name: "unconditional",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader(`{"id":1}`))
+			for _, edit := range tt.editors {
+				if err := edit(context.Background(), req); err != nil {
+					t.Fatalf("editor error = %v", err)
+				}
+			}
+			resp, err := Execute(NewDefaultClient(), req)
+			if tt.wantFail {
+				var herr *HTTPError
+				if !errors.Is(err, ErrPreconditionFailed) || !errors.As(err, &herr) || herr.StatusCode != http.StatusPreconditionFailed {
+					t.Errorf("Execute() error = %v, want ErrPreconditionFailed wrapping a 412 *HTTPError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if string(resp.Body) != `{"id":1}` {
+				t.Errorf("Body = %q, want the echoed payload", resp.Body)
+			}
+		})
+	}
+}
+
+func TestWithIfMatch_RequestEditor(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("If-Match")
+	}))
+	defer srv.Close()
+
+	c := NewDefaultClient(WithRequestEditors(WithIfMatch(`"v2"`)))
+	req, _ := http.NewRequest(http.MethodDelete, srv.URL, nil)
+	if _, err := Execute(c, req); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got != `"v2"` {
+		t.Errorf("If-Match = %q, want %q", got, `"v2"`)
+	}
+}
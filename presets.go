@@ -0,0 +1,37 @@
+package client
+
+import (
+	"crypto/tls"
+	"time"
+)
+
+// WithPreset bundles several options into one so reusable configurations
+// can be passed around. Options given after a preset override its values.
+func WithPreset(opts ...Option) Option {
+	return func(c *DefaultClient) {
+		for _, opt := range opts {
+			opt(c)
+		}
+	}
+}
+
+// InternalServicePreset suits calls to services inside the same network:
+// tight timeouts and quick retries.
+func InternalServicePreset() Option {
+	return WithPreset(
+		WithTimeout(5*time.Second),
+		WithPerTryTimeout(2*time.Second),
+		WithRetry(3, ExponentialBackoff(50*time.Millisecond, time.Second)),
+	)
+}
+
+// PublicAPIPreset suits calls to third-party APIs over the internet:
+// generous timeouts, TLS 1.2 or later and a bounded response size.
+func PublicAPIPreset() Option {
+	return WithPreset(
+		WithTimeout(30*time.Second),
+		WithRetry(3, DefaultBackoff),
+		WithTLSMinVersion(tls.VersionTLS12),
+		WithMaxResponseBytes(10<<20),
+	)
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+)
+
+func TestWithPreset(t *testing.T) {
+	preset := WithPreset(WithTimeout(time.Second), WithMaxResponseBytes(1024))
+
+	tests := []struct {
+		name         string
+		opts         []Option
+		wantTimeout  time.Duration
+		wantMaxBytes int64
+	}{
+		{
+			name:         "preset applies its options",
+			opts:         []Option{preset},
+			wantTimeout:  time.Second,
+			wantMaxBytes: 1024,
+		},
+		{
+			name:         "later option overrides preset",
+			opts:         []Option{preset, WithTimeout(3 * time.Second)},
+			wantTimeout:  3 * time.Second,
+			wantMaxBytes: 1024,
+		},
+		{
+			name:         "preset overrides earlier option",
+			opts:         []Option{WithMaxResponseBytes(1), preset},
+			wantTimeout:  time.Second,
+			wantMaxBytes: 1024,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewDefaultClient(tt.opts...)
+			if c.timeout != tt.wantTimeout {
+				t.Errorf("timeout = %v, want %v", c.timeout, tt.wantTimeout)
+			}
+			if c.maxResponseBytes != tt.wantMaxBytes {
+				t.Errorf("maxResponseBytes = %d, want %d", c.maxResponseBytes, tt.wantMaxBytes)
+			}
+		})
+	}
+}
+
+func TestBuiltinPresets(t *testing.T) {
+	internal := NewDefaultClient(InternalServicePreset())
+	if internal.timeout != 5*time.Second || internal.perTryTimeout != 2*time.Second {
+		t.Errorf("internal preset timeouts = %v/%v, want 5s/2s", internal.timeout, internal.perTryTimeout)
+	}
+	if internal.maxAttempts != 3 {
+		t.Errorf("internal preset maxAttempts = %d, want 3", internal.maxAttempts)
+	}
+
+	public := NewDefaultClient(PublicAPIPreset(), WithTimeout(time.Minute))
+	if public.timeout != time.Minute {
+		t.Errorf("public preset timeout = %v, want override of 1m", public.timeout)
+	}
+	if got := public.transport.TLSClientConfig.MinVersion; got != tls.VersionTLS12 {
+		t.Errorf("public preset TLS MinVersion = %x, want TLS 1.2", got)
+	}
+	if public.maxResponseBytes != 10<<20 {
+		t.Errorf("public preset maxResponseBytes = %d, want %d", public.maxResponseBytes, 10<<20)
+	}
+}
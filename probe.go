@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Probe checks that url is reachable, returning nil on any 2xx response and
+// an *HTTPError otherwise. It sends a HEAD request, falling back to GET for
+// servers that do not support HEAD, and never reads the body.
+func (c *DefaultClient) Probe(ctx context.Context, url string) error {
+	status, err := c.probe(ctx, http.MethodHead, url)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = c.probe(ctx, http.MethodGet, url)
+	}
+	if err != nil {
+		return c.mapError(fmt.Errorf("failed to fetch data: %w", err))
+	}
+	if status < 200 || status > 299 {
+		return c.mapError(&HTTPError{StatusCode: status})
+	}
+	return nil
+}
+
+func (c *DefaultClient) probe(ctx context.Context, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
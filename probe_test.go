@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDefaultClient_Probe(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/get-only", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/unavailable", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedURL := closed.URL
+	closed.Close()
+
+	tests := []struct {
+		name       string
+		url        string
+		wantErr    bool
+		wantStatus int
+	}{
+		{
+			name: "reachable",
+			url:  srv.URL + "/healthz",
+		},
+		{
+			name: "HEAD not allowed falls back to GET",
+			url:  srv.URL + "/get-only",
+		},
+		{
+			name:       "non-2xx",
+			url:        srv.URL + "/unavailable",
+			wantErr:    true,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:    "unreachable",
+			url:     closedURL,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewDefaultClient().Probe(context.Background(), tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Probe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantStatus != 0 {
+				var httpErr *HTTPError
+				if !errors.As(err, &httpErr) || httpErr.StatusCode != tt.wantStatus {
+					t.Errorf("Probe() error = %v, want HTTPError %d", err, tt.wantStatus)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultClient_Probe_Cancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := NewDefaultClient().Probe(ctx, srv.URL)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Probe() error = %v, want context.Canceled", err)
+	}
+}
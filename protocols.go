@@ -0,0 +1,28 @@
+package client
+
+import "net/http"
+
+// WithForceHTTP2 makes the client speak HTTP/2 only. Over TLS the protocol
+// is negotiated with ALPN and servers without HTTP/2 support fail; plain
+// http:// URLs use unencrypted HTTP/2 with prior knowledge (h2c).
+func WithForceHTTP2() Option {
+	return func(c *DefaultClient) {
+		var p http.Protocols
+		p.SetHTTP2(true)
+		p.SetUnencryptedHTTP2(true)
+		c.transport.Protocols = &p
+		c.transport.ForceAttemptHTTP2 = true
+	}
+}
+
+// WithDisableHTTP2 makes the client speak HTTP/1.1 only, even when a TLS
+// server offers HTTP/2 through ALPN.
+func WithDisableHTTP2() Option {
+	return func(c *DefaultClient) {
+		var p http.Protocols
+		p.SetHTTP1(true)
+		c.transport.Protocols = &p
+		c.transport.ForceAttemptHTTP2 = false
+		c.tlsConfig().NextProtos = []string{"http/1.1"}
+	}
+}
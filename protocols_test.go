@@ -0,0 +1,60 @@
+package client
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTP2Toggle(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		opts         []Option
+		wantH2       bool
+		wantProtocol string
+	}{
+		{
+			name:         "force HTTP/2",
+			opts:         []Option{WithForceHTTP2()},
+			wantH2:       true,
+			wantProtocol: "HTTP/2.0",
+		},
+		{
+			name:         "disable HTTP/2",
+			opts:         []Option{WithDisableHTTP2()},
+			wantProtocol: "HTTP/1.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewDefaultClient(tt.opts...)
+			trustServer(c, srv)
+
+			var negotiated string
+			c.tlsConfig().VerifyConnection = func(cs tls.ConnectionState) error {
+				negotiated = cs.NegotiatedProtocol
+				return nil
+			}
+
+			body, err := FetchDataFrom(c, srv.URL)
+			if err != nil {
+				t.Fatalf("FetchDataFrom() error = %v", err)
+			}
+			if (negotiated == "h2") != tt.wantH2 {
+				t.Errorf("negotiated protocol = %q, want h2 = %v", negotiated, tt.wantH2)
+			}
+			if string(body) != tt.wantProtocol {
+				t.Errorf("server saw %q, want %q", body, tt.wantProtocol)
+			}
+		})
+	}
+}
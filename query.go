@@ -0,0 +1,39 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// WithDefaultQuery merges params into the query string of every request,
+// for APIs that expect fixed parameters such as api_key or format. Keys
+// already present on a request keep their values.
+func WithDefaultQuery(params url.Values) Option {
+	params = cloneValues(params)
+	return func(c *DefaultClient) {
+		c.defaultQuery = params
+	}
+}
+
+func (c *DefaultClient) applyDefaultQuery(req *http.Request) *http.Request {
+	if len(c.defaultQuery) == 0 {
+		return req
+	}
+	q := req.URL.Query()
+	for key, values := range c.defaultQuery {
+		if _, ok := q[key]; !ok {
+			q[key] = values
+		}
+	}
+	req = req.Clone(req.Context())
+	req.URL.RawQuery = q.Encode()
+	return req
+}
+
+func cloneValues(v url.Values) url.Values {
+	out := make(url.Values, len(v))
+	for key, values := range v {
+		out[key] = append([]string(nil), values...)
+	}
+	return out
+}
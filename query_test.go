@@ -0,0 +1,45 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestWithDefaultQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want url.Values
+	}{
+		{
+			name: "defaults added",
+			path: "/posts",
+			want: url.Values{"api_key": {"secret"}, "format": {"json"}},
+		},
+		{
+			name: "request values take precedence",
+			path: "/posts?format=xml&page=2",
+			want: url.Values{"api_key": {"secret"}, "format": {"xml"}, "page": {"2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got url.Values
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.URL.Query()
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(WithDefaultQuery(url.Values{"api_key": {"secret"}, "format": {"json"}}))
+			if _, err := FetchDataFrom(c, srv.URL+tt.path); err != nil {
+				t.Fatalf("FetchDataFrom() error = %v", err)
+			}
+			if got.Encode() != tt.want.Encode() {
+				t.Errorf("query = %q, want %q", got.Encode(), tt.want.Encode())
+			}
+		})
+	}
+}
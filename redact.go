@@ -0,0 +1,47 @@
+package client
+
+import "net/http"
+
+// Redacted replaces the value of a sensitive header in dumps and logs.
+const Redacted = "[REDACTED]"
+
+var defaultRedactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// WithRedactedHeaders sets the headers whose values are replaced with
+// Redacted in cassettes and by RedactHeaders, replacing the default of
+// Authorization, Cookie, Set-Cookie and X-Api-Key. Names are matched case
+// insensitively.
+func WithRedactedHeaders(names ...string) Option {
+	return func(c *DefaultClient) {
+		c.redactedHeaders = headerSet(names)
+	}
+}
+
+// RedactHeaders returns a copy of h with the values of sensitive headers
+// replaced with Redacted, for logging.
+func (c *DefaultClient) RedactHeaders(h http.Header) http.Header {
+	set := c.redactedHeaders
+	if set == nil {
+		set = headerSet(defaultRedactedHeaders)
+	}
+	out := h.Clone()
+	for name, values := range out {
+		if !set[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		redacted := make([]string, len(values))
+		for i := range redacted {
+			redacted[i] = Redacted
+		}
+		out[name] = redacted
+	}
+	return out
+}
+
+func headerSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[http.CanonicalHeaderKey(name)] = true
+	}
+	return set
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactHeaders(t *testing.T) {
+	header := http.Header{
+		"Authorization": {"Bearer secret"},
+		"Cookie":        {"session=secret"},
+		"X-Api-Key":     {"secret"},
+		"X-Tenant":      {"acme"},
+		"Content-Type":  {"application/json"},
+	}
+	tests := []struct {
+		name         string
+		opts         []Option
+		wantRedacted []string
+		wantKept     []string
+	}{
+		{
+			name:         "defaults",
+			wantRedacted: []string{"Authorization", "Cookie", "X-Api-Key"},
+			wantKept:     []string{"X-Tenant", "Content-Type"},
+		},
+		{
+			name:         "custom list",
+			opts:         []Option{WithRedactedHeaders("x-tenant", "AUTHORIZATION")},
+			wantRedacted: []string{"X-Tenant", "Authorization"},
+			wantKept:     []string{"Cookie", "X-Api-Key", "Content-Type"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewDefaultClient(tt.opts...).RedactHeaders(header)
+			for _, name := range tt.wantRedacted {
+				if v := got.Get(name); v != Redacted {
+					t.Errorf("%s = %q, want %q", name, v, Redacted)
+				}
+			}
+			for _, name := range tt.wantKept {
+				if v, want := got.Get(name), header.Get(name); v != want {
+					t.Errorf("%s = %q, want %q", name, v, want)
+				}
+			}
+			if header.Get("Authorization") != "Bearer secret" {
+				t.Error("RedactHeaders modified its input")
+			}
+		})
+	}
+}
+
+func TestWithRedactedHeaders_Cassette(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("X-Internal-Token", "secret")
+		w.Header().Set("X-Request-Id", "abc")
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	c := NewDefaultClient(WithCassette(path, ModeRecord), WithRedactedHeaders("Set-Cookie", "x-internal-token"))
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("Set-Cookie"); got != "session=secret" {
+		t.Errorf("caller saw Set-Cookie %q, want the real value", got)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("cassette leaks a redacted value:\n%s", data)
+	}
+	if !strings.Contains(string(data), "abc") {
+		t.Errorf("cassette is missing X-Request-Id:\n%s", data)
+	}
+}
@@ -0,0 +1,22 @@
+package client
+
+import "net/http"
+
+// WithNoRedirects stops the client from following redirects: a 3xx response
+// other than 304 Not Modified is returned as an *HTTPError instead, so an
+// unexpected redirect from a fixed API surfaces rather than being followed.
+func WithNoRedirects() Option {
+	return func(c *DefaultClient) {
+		c.noRedirects = true
+	}
+}
+
+// redirectError returns the error for resp when redirects are disabled and
+// resp is one, closing its body.
+func (c *DefaultClient) redirectError(resp *http.Response) error {
+	if !c.noRedirects || resp.StatusCode < 300 || resp.StatusCode > 399 || resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	defer resp.Body.Close()
+	return statusError(c, resp)
+}
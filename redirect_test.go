@@ -0,0 +1,80 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWithNoRedirects(t *testing.T) {
+	var followed atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		followed.Store(true)
+		w.Write([]byte("new"))
+	})
+	mux.HandleFunc("/unchanged", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		opts         []Option
+		wantStatus   int // of the *HTTPError, 0 for success
+		wantFollowed bool
+	}{
+		{
+			name:         "followed by default",
+			wantFollowed: true,
+		},
+		{
+			name:       "302 becomes an error",
+			opts:       []Option{WithNoRedirects()},
+			wantStatus: http.StatusFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			followed.Store(false)
+			body, err := FetchDataFrom(NewDefaultClient(tt.opts...), srv.URL+"/old")
+			var herr *HTTPError
+			switch {
+			case tt.wantStatus == 0 && err != nil:
+				t.Fatalf("FetchDataFrom() error = %v", err)
+			case tt.wantStatus == 0 && string(body) != "new":
+				t.Errorf("body = %q, want %q", body, "new")
+			case tt.wantStatus != 0 && (!errors.As(err, &herr) || herr.StatusCode != tt.wantStatus):
+				t.Errorf("err = %v, want an *HTTPError with status %d", err, tt.wantStatus)
+			}
+			if followed.Load() != tt.wantFollowed {
+				t.Errorf("redirect followed = %v, want %v", followed.Load(), tt.wantFollowed)
+			}
+		})
+	}
+
+	t.Run("Do surfaces the redirect", func(t *testing.T) {
+		_, err := NewDefaultClient(WithNoRedirects()).Get(srv.URL + "/old")
+		var herr *HTTPError
+		if !errors.As(err, &herr) || herr.StatusCode != http.StatusFound {
+			t.Errorf("err = %v, want an *HTTPError with status 302", err)
+		}
+	})
+
+	t.Run("304 is not a redirect", func(t *testing.T) {
+		resp, err := NewDefaultClient(WithNoRedirects()).Get(srv.URL + "/unchanged")
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotModified {
+			t.Errorf("status = %d, want 304", resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ErrRedirectBodyTooLarge is returned when a 307 or 308 redirect requires
+// resending a streamed request body larger than WithMaxRedirectBodyReplay
+// allows.
+var ErrRedirectBodyTooLarge = errors.New("request body too large to replay on redirect")
+
+// WithMaxRedirectBodyReplay keeps a copy of streamed request bodies up to
+// maxBytes as they are sent, so 307 and 308 redirects, which must resend the
+// body, can be followed. Larger bodies fail the redirect with
+// ErrRedirectBodyTooLarge. Bodies the standard library can already replay,
+// such as bytes.Reader or strings.Reader, are unaffected. Without it, a
+// redirect that needs a streamed body is returned to the caller unfollowed.
+func WithMaxRedirectBodyReplay(maxBytes int64) Option {
+	return func(c *DefaultClient) {
+		c.maxRedirectReplay = maxBytes
+	}
+}
+
+// replayable makes req's streamed body resendable on redirect. It is applied
+// per try, so retries still treat the body as consumed.
+func (c *DefaultClient) replayable(req *http.Request) *http.Request {
+	if c.maxRedirectReplay <= 0 || req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return req
+	}
+	rb := &replayBody{rc: req.Body, max: c.maxRedirectReplay}
+	req = req.Clone(req.Context())
+	req.Body = rb
+	req.GetBody = rb.replay
+	return req
+}
+
+// replayBody records what is read through it, up to max bytes.
+type replayBody struct {
+	rc  io.ReadCloser
+	max int64
+
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	eof      bool
+	overflow bool
+}
+
+func (b *replayBody) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.record(p[:n])
+	if err == io.EOF {
+		b.eof = true
+	}
+	return n, err
+}
+
+func (b *replayBody) record(p []byte) {
+	if b.overflow {
+		return
+	}
+	if int64(b.buf.Len()+len(p)) > b.max {
+		b.overflow = true
+		b.buf = bytes.Buffer{}
+		return
+	}
+	b.buf.Write(p)
+}
+
+func (b *replayBody) Close() error {
+	return b.rc.Close()
+}
+
+func (b *replayBody) replay() (io.ReadCloser, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.eof && !b.overflow {
+		return nil, errors.New("request body was not fully sent before redirect")
+	}
+	if b.overflow {
+		return nil, fmt.Errorf("%w (limit %d bytes)", ErrRedirectBodyTooLarge, b.max)
+	}
+	return io.NopCloser(bytes.NewReader(b.buf.Bytes())), nil
+}
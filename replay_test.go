@@ -0,0 +1,64 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithMaxRedirectBodyReplay(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		http.Redirect(w, r, "/new", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		size    int
+		wantErr error
+	}{
+		{
+			name: "small body replayed",
+			size: 512,
+		},
+		{
+			name:    "large body errors",
+			size:    4096,
+			wantErr: ErrRedirectBodyTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := strings.Repeat("x", tt.size)
+			// NopCloser hides the reader type, so the request has no GetBody.
+			req, _ := http.NewRequest(http.MethodPost, srv.URL+"/old", io.NopCloser(strings.NewReader(payload)))
+
+			resp, err := Execute(NewDefaultClient(WithMaxRedirectBodyReplay(1024)), req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if string(resp.Body) != payload {
+				t.Errorf("redirected body = %d bytes, want %d", len(resp.Body), len(payload))
+			}
+			if resp.FinalURL != srv.URL+"/new" {
+				t.Errorf("FinalURL = %q, want /new", resp.FinalURL)
+			}
+		})
+	}
+}
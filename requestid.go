@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader is the header used to propagate request IDs.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying id under the package's
+// default request-ID key.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// WithRequestID sets the X-Request-ID header on every outgoing request. The
+// ID is taken from the request context when present, otherwise a new one is
+// generated.
+func WithRequestID() Option {
+	return func(c *DefaultClient) {
+		if c.requestIDKey == nil {
+			c.requestIDKey = requestIDKey{}
+		}
+	}
+}
+
+// WithContextRequestIDKey reads request IDs from the context value stored
+// under key, so IDs set by existing middleware (gin, chi, ...) are propagated.
+// It enables request-ID injection.
+func WithContextRequestIDKey(key any) Option {
+	return func(c *DefaultClient) {
+		c.requestIDKey = key
+	}
+}
+
+func (c *DefaultClient) injectRequestID(req *http.Request) *http.Request {
+	if c.requestIDKey == nil || req.Header.Get(RequestIDHeader) != "" {
+		return req
+	}
+	id, _ := req.Context().Value(c.requestIDKey).(string)
+	if id == "" {
+		id = newRequestID()
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set(RequestIDHeader, id)
+	return req
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type middlewareKey struct{}
+
+func TestRequestIDPropagation(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		ctx    context.Context
+		wantID string
+	}{
+		{
+			name:   "custom context key propagated",
+			opts:   []Option{WithContextRequestIDKey(middlewareKey{})},
+			ctx:    context.WithValue(context.Background(), middlewareKey{}, "from-middleware"),
+			wantID: "from-middleware",
+		},
+		{
+			name:   "string context key propagated",
+			opts:   []Option{WithContextRequestIDKey("requestid")},
+			ctx:    context.WithValue(context.Background(), "requestid", "chi-id"),
+			wantID: "chi-id",
+		},
+		{
+			name:   "default key propagated",
+			opts:   []Option{WithRequestID()},
+			ctx:    ContextWithRequestID(context.Background(), "default-id"),
+			wantID: "default-id",
+		},
+		{
+			name:   "custom key ignores default key",
+			opts:   []Option{WithContextRequestIDKey(middlewareKey{})},
+			ctx:    ContextWithRequestID(context.Background(), "default-id"),
+			wantID: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get(RequestIDHeader)
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(tt.opts...)
+			req, err := http.NewRequestWithContext(tt.ctx, http.MethodGet, srv.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := c.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if got == "" {
+				t.Fatal("expected a request ID header")
+			}
+			if tt.wantID != "" && got != tt.wantID {
+				t.Errorf("request ID = %q, want %q", got, tt.wantID)
+			}
+			if tt.wantID == "" && got == "default-id" {
+				t.Errorf("request ID = %q, want a generated ID", got)
+			}
+		})
+	}
+}
+
+func TestRequestIDDisabledByDefault(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(RequestIDHeader)
+	}))
+	defer srv.Close()
+
+	resp, err := NewDefaultClient().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if got != "" {
+		t.Errorf("request ID = %q, want none", got)
+	}
+}
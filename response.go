@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Response is a fully read HTTP response along with its metadata.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	// Trailer holds trailer values such as grpc-status. Trailers only
+	// arrive after the body, so they are captured once it is fully read.
+	Trailer http.Header
+
+	// FinalURL is the URL the body was served from, after any redirects.
+	FinalURL string
+
+	// RemoteAddr is the IP address and port of the server the client was
+	// connected to when the response arrived. It is empty when no
+	// connection was made, e.g. for responses served from cache.
+	RemoteAddr string
+}
+
+// FetchResponse fetches url like FetchDataFrom but also returns the response
+// metadata.
+func FetchResponse(client HTTPClient, url string) (*Response, error) {
+	resp, err := fetch(context.Background(), client, url)
+	if err != nil {
+		return nil, mapError(client, err)
+	}
+	return resp, nil
+}
+
+// WithRetainedHeaders keeps only the named headers in the Header of the
+// Response returned by the fetch helpers, so high-volume callers do not hold
+// on to whole header maps. With no names every header is retained.
+func WithRetainedHeaders(names ...string) Option {
+	return func(c *DefaultClient) {
+		c.retainedHeaders = nil
+		if len(names) > 0 {
+			c.retainedHeaders = headerSet(names)
+		}
+	}
+}
+
+// headerRetainer is implemented by clients that trim Response headers.
+type headerRetainer interface {
+	retainHeaders(h http.Header) http.Header
+}
+
+func (c *DefaultClient) retainHeaders(h http.Header) http.Header {
+	if c.retainedHeaders == nil {
+		return h
+	}
+	kept := make(http.Header, len(c.retainedHeaders))
+	for name := range c.retainedHeaders {
+		if values, ok := h[name]; ok {
+			kept[name] = values
+		}
+	}
+	return kept
+}
+
+func newResponse(resp *http.Response, url string, body []byte) *Response {
+	finalURL := url
+	if resp.Request != nil && resp.Request.URL != nil {
+		finalURL = resp.Request.URL.String()
+	}
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+		Trailer:    resp.Trailer,
+		FinalURL:   finalURL,
+	}
+}
+
+// Execute sends a caller-built request through client, with the same retry,
+// status checking and body reading as the fetch helpers, and returns the
+// fully read response. Any 2xx status is a success; others are returned as
+// *HTTPError, and 412 additionally as ErrPreconditionFailed. client must
+// implement Doer.
+func Execute(client HTTPClient, req *http.Request) (*Response, error) {
+	if _, ok := client.(Doer); !ok {
+		return nil, errors.New("client does not implement Doer")
+	}
+	var status int
+	resp, err := execute(client, req, func(s int) bool {
+		status = s
+		return s >= 200 && s <= 299
+	})
+	if err != nil {
+		if status == http.StatusPreconditionFailed {
+			err = fmt.Errorf("%w: %w", ErrPreconditionFailed, err)
+		}
+		return nil, mapError(client, err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,291 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchResponse_FinalURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		path         string
+		wantFinalURL string
+	}{
+		{
+			name:         "redirect target reported",
+			path:         "/old",
+			wantFinalURL: srv.URL + "/new",
+		},
+		{
+			name:         "no redirect",
+			path:         "/new",
+			wantFinalURL: srv.URL + "/new",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := FetchResponse(NewDefaultClient(), srv.URL+tt.path)
+			if err != nil {
+				t.Fatalf("FetchResponse() error = %v", err)
+			}
+			if resp.FinalURL != tt.wantFinalURL {
+				t.Errorf("FinalURL = %q, want %q", resp.FinalURL, tt.wantFinalURL)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("StatusCode = %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+			if got := resp.Header.Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			if string(resp.Body) != `[]` {
+				t.Errorf("Body = %q, want []", resp.Body)
+			}
+		})
+	}
+}
+
+func TestFetchResponse_FinalURLWithoutRequest(t *testing.T) {
+	mock := &mockHTTPClient{
+		doFunc: func(url string) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`[]`)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+
+	resp, err := FetchResponse(mock, Endpoint)
+	if err != nil {
+		t.Fatalf("FetchResponse() error = %v", err)
+	}
+	if resp.FinalURL != Endpoint {
+		t.Errorf("FinalURL = %q, want %q", resp.FinalURL, Endpoint)
+	}
+}
+
+func TestFetchResponse_Trailer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		w.Write([]byte(`[]`))
+		w.(http.Flusher).Flush()
+		w.Header().Set("Grpc-Status", "0")
+		w.Header().Set("Grpc-Message", "OK")
+	}))
+	defer srv.Close()
+
+	resp, err := FetchResponse(NewDefaultClient(), srv.URL)
+	if err != nil {
+		t.Fatalf("FetchResponse() error = %v", err)
+	}
+	if got := resp.Trailer.Get("Grpc-Status"); got != "0" {
+		t.Errorf("Trailer Grpc-Status = %q, want %q", got, "0")
+	}
+	if got := resp.Trailer.Get("Grpc-Message"); got != "OK" {
+		t.Errorf("Trailer Grpc-Message = %q, want %q", got, "OK")
+	}
+	if string(resp.Body) != `[]` {
+		t.Errorf("Body = %q, want []", resp.Body)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPut || r.Header.Get("If-Match") != `"v1"` {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		w.Header().Set("ETag", `"v2"`)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		ifMatch    string
+		wantStatus int
+		wantErr    bool
+	}{
+		{
+			name:       "custom PUT succeeds",
+			ifMatch:    `"v1"`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "non-2xx is an HTTPError",
+			ifMatch:    `"v0"`,
+			wantStatus: http.StatusPreconditionFailed,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPut, srv.URL+"/posts/1", strings.NewReader(`{"id":1}`))
+			req.Header.Set("If-Match", tt.ifMatch)
+
+			resp, err := Execute(NewDefaultClient(), req)
+			if tt.wantErr {
+				var httpErr *HTTPError
+				if !errors.As(err, &httpErr) || httpErr.StatusCode != tt.wantStatus {
+					t.Fatalf("Execute() error = %v, want HTTPError %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("StatusCode = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := resp.Header.Get("ETag"); got != `"v2"` {
+				t.Errorf("ETag = %q, want %q", got, `"v2"`)
+			}
+			if string(resp.Body) != `{"id":1}` {
+				t.Errorf("Body = %q", resp.Body)
+			}
+			if resp.FinalURL != srv.URL+"/posts/1" {
+				t.Errorf("FinalURL = %q", resp.FinalURL)
+			}
+		})
+	}
+}
+
+func TestExecute_RequiresDoer(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPut, "http://example.invalid", nil)
+	getOnly := &mockHTTPClient{doFunc: func(string) (*http.Response, error) {
+		t.Fatal("Get called")
+		return nil, nil
+	}}
+	if _, err := Execute(getOnly, req); err == nil {
+		t.Error("Execute() error = nil, want error for client without Do")
+	}
+}
+
+func TestWithRetainedHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("X-Request-Id", "abc")
+		w.Header().Set("X-Debug", "verbose")
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		wantKept []string
+		wantGone []string
+	}{
+		{
+			name:     "all retained by default",
+			wantKept: []string{"Etag", "X-Request-Id", "X-Debug", "Content-Type"},
+		},
+		{
+			name:     "empty list retains all",
+			opts:     []Option{WithRetainedHeaders()},
+			wantKept: []string{"Etag", "X-Request-Id", "X-Debug"},
+		},
+		{
+			name:     "only listed headers",
+			opts:     []Option{WithRetainedHeaders("etag", "X-Request-ID", "X-Missing")},
+			wantKept: []string{"Etag", "X-Request-Id"},
+			wantGone: []string{"X-Debug", "Content-Type", "Date", "X-Missing"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := FetchResponse(NewDefaultClient(tt.opts...), srv.URL)
+			if err != nil {
+				t.Fatalf("FetchResponse() error = %v", err)
+			}
+			for _, name := range tt.wantKept {
+				if resp.Header.Get(name) == "" {
+					t.Errorf("header %s missing", name)
+				}
+			}
+			for _, name := range tt.wantGone {
+				if _, ok := resp.Header[http.CanonicalHeaderKey(name)]; ok {
+					t.Errorf("header %s retained", name)
+				}
+			}
+			if string(resp.Body) != "ok" {
+				t.Errorf("body = %q, want %q", resp.Body, "ok")
+			}
+		})
+	}
+}
+
+func TestFetchResponse_RemoteAddr(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	c := NewDefaultClient(WithCache(NewMemoryCache()))
+	resp, err := FetchResponse(c, srv.URL)
+	if err != nil {
+		t.Fatalf("FetchResponse() error = %v", err)
+	}
+	if want := srv.Listener.Addr().String(); resp.RemoteAddr != want {
+		t.Errorf("RemoteAddr = %q, want %q", resp.RemoteAddr, want)
+	}
+	if host, _, err := net.SplitHostPort(resp.RemoteAddr); err != nil || !net.ParseIP(host).IsLoopback() {
+		t.Errorf("RemoteAddr = %q, want a loopback address", resp.RemoteAddr)
+	}
+
+	resp, err = FetchResponse(c, srv.URL)
+	if err != nil {
+		t.Fatalf("FetchResponse() error = %v", err)
+	}
+	if resp.RemoteAddr != "" {
+		t.Errorf("cached RemoteAddr = %q, want empty", resp.RemoteAddr)
+	}
+}
+
+func TestFetchResponse_RemoteAddrOfWinningTry(t *testing.T) {
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(`[]`))
+	}))
+	defer fast.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	// The first try goes to fast, which still answers before the hedge
+	// sent to slow after 10ms, so the hedge's connection is the last made.
+	c := NewDefaultClient(WithLoadBalancer(fast.URL, slow.URL), WithHedging(10*time.Millisecond))
+	resp, err := FetchResponse(c, fast.URL)
+	if err != nil {
+		t.Fatalf("FetchResponse() error = %v", err)
+	}
+	if want := fast.Listener.Addr().String(); resp.RemoteAddr != want {
+		t.Errorf("RemoteAddr = %q, want %q of the winning try", resp.RemoteAddr, want)
+	}
+}
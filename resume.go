@@ -0,0 +1,95 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxStreamResumes bounds how often one body is resumed.
+const maxStreamResumes = 3
+
+// WithResilientStreaming resumes a GET response body that fails mid-read by
+// requesting the rest with a Range header from the last byte received, so a
+// dropped connection does not lose a long download. It is best-effort: only
+// 200 responses from servers advertising Accept-Ranges: bytes are resumed,
+// and only when the server answers with the matching 206 partial content.
+// An ETag or Last-Modified validator is sent as If-Range so a changed
+// resource is never spliced. Range requests are sent like further tries of
+// the original request, with its retries, circuit breaker, load balancing
+// and per-try timeout; they share its rate limiter slot, request editors and
+// signature, and are not reported as requests of their own in metrics.
+func WithResilientStreaming() Option {
+	return func(c *DefaultClient) {
+		c.resilientStreaming = true
+	}
+}
+
+// resumes reports whether resp's body can be resumed with Range requests.
+func (c *DefaultClient) resumes(req *http.Request, resp *http.Response) bool {
+	return c.resilientStreaming && req.Method == http.MethodGet && resp.StatusCode == http.StatusOK &&
+		!resp.Uncompressed && resp.Header.Get("Content-Encoding") == "" &&
+		strings.EqualFold(resp.Header.Get("Accept-Ranges"), "bytes")
+}
+
+// resumingBody reads resp's body, reissuing a Range request for the rest
+// after a read error.
+type resumingBody struct {
+	c         *DefaultClient
+	req       *http.Request
+	validator string
+	rc        io.ReadCloser
+	offset    int64
+	resumes   int
+}
+
+func (c *DefaultClient) resumingBody(req *http.Request, resp *http.Response) *resumingBody {
+	validator := resp.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = resp.Header.Get("Last-Modified")
+	}
+	return &resumingBody{c: c, req: req, validator: validator, rc: resp.Body}
+}
+
+func (b *resumingBody) Read(p []byte) (int, error) {
+	for {
+		n, err := b.rc.Read(p)
+		b.offset += int64(n)
+		if err == nil || err == io.EOF || b.resumes >= maxStreamResumes || b.req.Context().Err() != nil {
+			return n, err
+		}
+		if rerr := b.resume(); rerr != nil {
+			return n, errors.Join(err, rerr)
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func (b *resumingBody) resume() error {
+	b.resumes++
+	req := b.req.Clone(b.req.Context())
+	req.Header.Set("Range", "bytes="+strconv.FormatInt(b.offset, 10)+"-")
+	if b.validator != "" {
+		req.Header.Set("If-Range", b.validator)
+	}
+	resp, err := b.c.doWithRetry(req)
+	if err != nil {
+		return fmt.Errorf("failed to resume body: %w", err)
+	}
+	if resp.StatusCode != http.StatusPartialContent || !strings.HasPrefix(resp.Header.Get("Content-Range"), "bytes "+strconv.FormatInt(b.offset, 10)+"-") {
+		resp.Body.Close()
+		return fmt.Errorf("failed to resume body: server answered %d to a range request", resp.StatusCode)
+	}
+	b.rc.Close()
+	b.rc = resp.Body
+	return nil
+}
+
+func (b *resumingBody) Close() error {
+	return b.rc.Close()
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// droppingServer serves payload, cutting the connection halfway through
+// the first response. Range requests are answered normally.
+func droppingServer(t *testing.T, payload []byte, etag string) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("Range") != "" {
+			w.Header().Set("ETag", `"v1"`)
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(payload))
+			return
+		}
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\nAccept-Ranges: bytes\r\nETag: %s\r\n\r\n", len(payload), etag)
+		buf.Write(payload[:len(payload)/2])
+		buf.Flush()
+	}))
+	return srv, &calls
+}
+
+func TestWithResilientStreaming(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789abcdef"), 16<<10)
+	tests := []struct {
+		name      string
+		opts      []Option
+		etag      string
+		wantErr   bool
+		wantCalls int64
+	}{
+		{
+			name:      "resumes after drop",
+			opts:      []Option{WithResilientStreaming()},
+			etag:      `"v1"`,
+			wantCalls: 2,
+		},
+		{
+			name:      "disabled",
+			etag:      `"v1"`,
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "changed resource not spliced",
+			opts:      []Option{WithResilientStreaming()},
+			etag:      `"v0"`,
+			wantErr:   true,
+			wantCalls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := droppingServer(t, payload, tt.etag)
+			defer srv.Close()
+
+			body, err := FetchDataFrom(NewDefaultClient(tt.opts...), srv.URL)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected a read error")
+				}
+			} else if err != nil {
+				t.Fatalf("FetchDataFrom() error = %v", err)
+			} else if !bytes.Equal(body, payload) {
+				t.Errorf("body has %d bytes, want the %d-byte payload", len(body), len(payload))
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("server calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWithResilientStreaming_RetriesResume(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789abcdef"), 16<<10)
+	var calls, ranges atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("Range") != "" {
+			if ranges.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(payload))
+			return
+		}
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\nAccept-Ranges: bytes\r\nETag: \"v1\"\r\n\r\n", len(payload))
+		buf.Write(payload[:len(payload)/2])
+		buf.Flush()
+	}))
+	defer srv.Close()
+
+	c := NewDefaultClient(WithResilientStreaming(), WithRetry(3, fastBackoff))
+	body, err := FetchDataFrom(c, srv.URL)
+	if err != nil {
+		t.Fatalf("FetchDataFrom() error = %v", err)
+	}
+	if !bytes.Equal(body, payload) {
+		t.Errorf("body has %d bytes, want the %d-byte payload", len(body), len(payload))
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("server calls = %d, want 3", got)
+	}
+}
@@ -0,0 +1,379 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backoff returns the base delay before the given retry attempt (1 for the
+// first retry). Jitter is applied on top by the client.
+type Backoff func(attempt int) time.Duration
+
+// ExponentialBackoff doubles the delay from base on each attempt, capped at max.
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		return d
+	}
+}
+
+// DefaultBackoff is used by WithRetry when no backoff is given.
+var DefaultBackoff = ExponentialBackoff(100*time.Millisecond, 5*time.Second)
+
+var retryableStatus = map[int]bool{
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// WithRetry retries idempotent requests up to maxAttempts in total on
+// network errors, 429 and 5xx responses, waiting backoff (with jitter)
+// between attempts, or as long as the response's Retry-After header asks.
+// A nil backoff uses DefaultBackoff.
+func WithRetry(maxAttempts int, backoff Backoff) Option {
+	return func(c *DefaultClient) {
+		if backoff == nil {
+			backoff = DefaultBackoff
+		}
+		c.maxAttempts = maxAttempts
+		c.backoff = backoff
+	}
+}
+
+// WithRetryOnEmptyBody also retries idempotent requests whose 2xx response
+// has an empty body, for backends that intermittently drop the payload.
+// 204 No Content and HEAD responses are never treated as empty. It takes
+// effect together with WithRetry.
+func WithRetryOnEmptyBody() Option {
+	return func(c *DefaultClient) {
+		c.retryEmptyBody = true
+	}
+}
+
+// WithConnectRetry retries a request up to maxAttempts in total when its
+// connection cannot be established, waiting backoff (with jitter) between
+// attempts. Nothing has reached the server at that point, so any method is
+// retried; responses, including 5xx, are left alone. A nil backoff uses
+// DefaultBackoff. Each try made under WithRetry gets its own connect
+// retries, so a request dials at most maxAttempts times the WithRetry
+// attempts; every connect retry also spends a WithRetryBudget token.
+func WithConnectRetry(maxAttempts int, backoff Backoff) Option {
+	return func(c *DefaultClient) {
+		if backoff == nil {
+			backoff = DefaultBackoff
+		}
+		c.connectAttempts = maxAttempts
+		c.connectBackoff = backoff
+	}
+}
+
+// WithRetryStatusCodes retries exactly on the given status codes, plus
+// network errors, replacing the default 429/5xx set. With no codes only
+// network errors are retried. It takes effect together with WithRetry.
+func WithRetryStatusCodes(codes ...int) Option {
+	return func(c *DefaultClient) {
+		c.retryStatus = make(map[int]bool, len(codes))
+		for _, code := range codes {
+			c.retryStatus[code] = true
+		}
+	}
+}
+
+// WithRetryableErrors retries only the errors that match one of errs with
+// errors.Is, e.g. a sentinel returned by a wrapped transport, replacing the
+// default of retrying every network error; with no errs that default is
+// kept. Status code retries are unaffected. It takes effect together with
+// WithRetry.
+func WithRetryableErrors(errs ...error) Option {
+	return func(c *DefaultClient) {
+		c.retryErrors = errs
+	}
+}
+
+// WithRetryableHeader lets the server decide: a non-2xx response whose
+// named header is "true" is retried and one where it is "false" is not,
+// whatever its status. Successful responses are never retried, and
+// responses without a valid boolean value follow the usual rules. It takes
+// effect together with WithRetry.
+func WithRetryableHeader(name string) Option {
+	return func(c *DefaultClient) {
+		c.retryHeader = name
+	}
+}
+
+// WithResponseErrorRetryClassifier lets classify mark 4xx responses as
+// retryable, such as a 409 Conflict from an optimistic concurrency check or
+// a 423 Locked. It is called for every 4xx response whose status is not
+// already retryable, and only idempotent requests are retried. It takes
+// effect together with WithRetry.
+func WithResponseErrorRetryClassifier(classify func(resp *http.Response) bool) Option {
+	return func(c *DefaultClient) {
+		c.retryClassifier = classify
+	}
+}
+
+// WithJitterSource seeds the retry jitter from src, making backoff sequences
+// reproducible in tests. By default a time-seeded source is used.
+func WithJitterSource(src rand.Source) Option {
+	return func(c *DefaultClient) {
+		c.jitter = newJitter(src)
+	}
+}
+
+type jitter struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func newJitter(src rand.Source) *jitter {
+	return &jitter{rng: rand.New(src)}
+}
+
+// apply returns a duration in [d/2, d).
+func (j *jitter) apply(d time.Duration) time.Duration {
+	half := int64(d / 2)
+	if half <= 0 {
+		return d
+	}
+	j.mu.Lock()
+	n := j.rng.Int63n(half)
+	j.mu.Unlock()
+	return time.Duration(half + n)
+}
+
+// WithMaxRetryAfter caps how long a Retry-After header may delay a retry;
+// longer server-requested waits are clamped to d.
+func WithMaxRetryAfter(d time.Duration) Option {
+	return func(c *DefaultClient) {
+		c.maxRetryAfter = d
+	}
+}
+
+// retryDelay returns how long to wait before the next attempt, preferring
+// the server's Retry-After over the backoff.
+func (c *DefaultClient) retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			if c.maxRetryAfter > 0 && d > c.maxRetryAfter {
+				d = c.maxRetryAfter
+			}
+			return d
+		}
+	}
+	return c.backoffDelay(attempt)
+}
+
+// ParseRetryAfter parses a Retry-After header value given either in
+// delta-seconds or as an HTTP date, returning how long to wait from now and
+// whether value was valid. A date in the past or negative seconds wait
+// zero.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func (c *DefaultClient) backoffDelay(attempt int) time.Duration {
+	return c.jitter.apply(c.backoff(attempt))
+}
+
+func (c *DefaultClient) doWithRetry(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		countAttempt(req, attempt)
+		resp, err := c.hedgedTry(req, func(r *http.Request) (*http.Response, error) {
+			return c.route(r, c.guardedTry)
+		})
+		if errors.Is(err, ErrCircuitOpen) {
+			return nil, err
+		}
+		retryStatus := err == nil && c.retriesStatus(resp)
+		if err == nil && !retryStatus {
+			c.budget.deposit()
+		}
+		if attempt >= c.maxAttempts || !c.shouldRetry(req, resp, err, retryStatus) || !c.budget.withdraw() {
+			return resp, err
+		}
+		if resp != nil {
+			drainBody(resp.Body)
+		}
+		if err := sleep(req.Context(), c.retryDelay(attempt, resp)); err != nil {
+			return nil, err
+		}
+		if req, err = rewind(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// guardedTry runs a single try through the circuit breaker, if any.
+func (c *DefaultClient) guardedTry(req *http.Request) (*http.Response, error) {
+	if c.breaker == nil {
+		return c.connectTry(req)
+	}
+	host := req.URL.Host
+	if err := c.breaker.allow(host); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	resp, err := c.connectTry(req)
+	c.breaker.record(host, resp, err, time.Since(start))
+	return resp, err
+}
+
+// connectTry sends req, retrying dial failures under WithConnectRetry.
+func (c *DefaultClient) connectTry(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := c.tryOnce(req)
+		if attempt >= c.connectAttempts || !isDialError(err) || req.Context().Err() != nil ||
+			(req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) || !c.budget.withdraw() {
+			return resp, err
+		}
+		if err := sleep(req.Context(), c.jitter.apply(c.connectBackoff(attempt))); err != nil {
+			return nil, err
+		}
+		if req, err = rewind(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func isDialError(err error) bool {
+	var te *TransportError
+	return errors.As(err, &te) && te.Phase == PhaseDial
+}
+
+// retriesError reports whether err calls for a retry.
+func (c *DefaultClient) retriesError(err error) bool {
+	if len(c.retryErrors) == 0 {
+		return true
+	}
+	for _, target := range c.retryErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// retriesStatus reports whether resp calls for a retry: as its retryable
+// header says, if any and resp is not a 2xx, or else by status, consulting the classifier for
+// 4xx codes outside the retryable set.
+func (c *DefaultClient) retriesStatus(resp *http.Response) bool {
+	if c.retryHeader != "" && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		if retry, err := strconv.ParseBool(strings.TrimSpace(resp.Header.Get(c.retryHeader))); err == nil {
+			return retry
+		}
+	}
+	if c.retryStatus[resp.StatusCode] {
+		return true
+	}
+	return c.retryClassifier != nil && resp.StatusCode >= 400 && resp.StatusCode <= 499 && c.retryClassifier(resp)
+}
+
+// shouldRetry reports whether to try req again after resp or err;
+// retryStatus is the result of retriesStatus for resp.
+func (c *DefaultClient) shouldRetry(req *http.Request, resp *http.Response, err error, retryStatus bool) bool {
+	if !isIdempotent(req.Method) || (req.Body != nil && req.GetBody == nil) {
+		return false
+	}
+	if err != nil {
+		return req.Context().Err() == nil && c.retriesError(err)
+	}
+	if retryStatus {
+		return true
+	}
+	return c.retryEmptyBody && emptyBody(req, resp)
+}
+
+// emptyBody reports whether resp is a 2xx response that should have had a
+// body but has none. Bodies of unknown length are peeked at, and the peeked
+// byte is put back.
+func emptyBody(req *http.Request, resp *http.Response) bool {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || resp.StatusCode == http.StatusNoContent ||
+		req.Method == http.MethodHead || resp.ContentLength > 0 {
+		return false
+	}
+	if resp.ContentLength == 0 {
+		return true
+	}
+	var b [1]byte
+	n, err := resp.Body.Read(b[:])
+	if n > 0 {
+		resp.Body = &peekedBody{Reader: io.MultiReader(bytes.NewReader(b[:n]), resp.Body), rc: resp.Body}
+		return false
+	}
+	return err == io.EOF
+}
+
+type peekedBody struct {
+	io.Reader
+	rc io.ReadCloser
+}
+
+func (b *peekedBody) Close() error {
+	return b.rc.Close()
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// rewind returns a copy of req with a fresh body for another attempt.
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Body = body
+	return req, nil
+}
+
+// drainBody reads a bounded amount of body so the connection can be reused,
+// then closes it.
+func drainBody(body io.ReadCloser) {
+	io.Copy(io.Discard, io.LimitReader(body, 64<<10))
+	body.Close()
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,670 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastBackoff keeps retry tests quick.
+func fastBackoff(int) time.Duration { return time.Millisecond }
+
+func TestWithRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		statuses     []int
+		wantStatus   int
+		wantAttempts int32
+	}{
+		{
+			name:         "503 then success is retried",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantStatus:   http.StatusOK,
+			wantAttempts: 2,
+		},
+		{
+			name:         "gives up after max attempts",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK},
+			wantStatus:   http.StatusBadGateway,
+			wantAttempts: 3,
+		},
+		{
+			name:         "404 is not retried",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusNotFound, http.StatusOK},
+			wantStatus:   http.StatusNotFound,
+			wantAttempts: 1,
+		},
+		{
+			name:         "POST is not retried",
+			method:       http.MethodPost,
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantStatus:   http.StatusServiceUnavailable,
+			wantAttempts: 1,
+		},
+		{
+			name:         "PUT body is replayed",
+			method:       http.MethodPut,
+			statuses:     []int{http.StatusInternalServerError, http.StatusOK},
+			wantStatus:   http.StatusOK,
+			wantAttempts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := attempts.Add(1)
+				if r.Method == http.MethodPut {
+					body, err := io.ReadAll(r.Body)
+					if err != nil || string(body) != "payload" {
+						t.Errorf("attempt %d body = %q, want payload", n, body)
+					}
+				}
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(WithRetry(3, fastBackoff))
+			req, err := http.NewRequest(tt.method, srv.URL, strings.NewReader("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := c.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("StatusCode = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff(100*time.Millisecond, time.Second)
+
+	want := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, w := range want {
+		if got := b(i + 1); got != w {
+			t.Errorf("attempt %d delay = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestWithJitterSource(t *testing.T) {
+	sequence := func(c *DefaultClient) []time.Duration {
+		var delays []time.Duration
+		for attempt := 1; attempt <= 8; attempt++ {
+			delays = append(delays, c.backoffDelay(attempt))
+		}
+		return delays
+	}
+
+	a := sequence(NewDefaultClient(WithJitterSource(rand.NewSource(42))))
+	b := sequence(NewDefaultClient(WithJitterSource(rand.NewSource(42))))
+	other := sequence(NewDefaultClient(WithJitterSource(rand.NewSource(7))))
+
+	for i := range a {
+		if a[i] != b[i] {
+			t.Errorf("attempt %d: same seed gave %v and %v", i+1, a[i], b[i])
+		}
+		base := DefaultBackoff(i + 1)
+		if a[i] < base/2 || a[i] >= base {
+			t.Errorf("attempt %d delay = %v, want within [%v, %v)", i+1, a[i], base/2, base)
+		}
+	}
+
+	same := true
+	for i := range a {
+		if a[i] != other[i] {
+			same = false
+		}
+	}
+	if same {
+		t.Error("different seeds produced identical backoff sequences")
+	}
+}
+
+func TestWithRetryStatusCodes(t *testing.T) {
+	tests := []struct {
+		name         string
+		codes        []int
+		first        int
+		wantAttempts int32
+	}{
+		{
+			name:         "listed code retried",
+			codes:        []int{http.StatusConflict, http.StatusServiceUnavailable},
+			first:        http.StatusConflict,
+			wantAttempts: 2,
+		},
+		{
+			name:         "unlisted code not retried",
+			codes:        []int{http.StatusConflict},
+			first:        http.StatusServiceUnavailable,
+			wantAttempts: 1,
+		},
+		{
+			name:         "empty set does not retry status codes",
+			codes:        nil,
+			first:        http.StatusServiceUnavailable,
+			wantAttempts: 1,
+		},
+		{
+			name:         "empty set still retries network errors",
+			codes:        nil,
+			first:        0,
+			wantAttempts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) > 1 {
+					return
+				}
+				if tt.first == 0 {
+					conn, _, _ := w.(http.Hijacker).Hijack()
+					conn.Close()
+					return
+				}
+				w.WriteHeader(tt.first)
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(WithRetry(3, fastBackoff), WithRetryStatusCodes(tt.codes...))
+			resp, err := c.Get(srv.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestWithResponseErrorRetryClassifier(t *testing.T) {
+	conflict := func(resp *http.Response) bool { return resp.StatusCode == http.StatusConflict }
+	tests := []struct {
+		name         string
+		opts         []Option
+		status       int
+		wantAttempts int32
+	}{
+		{
+			name:         "409 not retried by default",
+			status:       http.StatusConflict,
+			wantAttempts: 1,
+		},
+		{
+			name:         "409 retried when classified",
+			opts:         []Option{WithResponseErrorRetryClassifier(conflict)},
+			status:       http.StatusConflict,
+			wantAttempts: 2,
+		},
+		{
+			name:         "other 4xx not retried",
+			opts:         []Option{WithResponseErrorRetryClassifier(conflict)},
+			status:       http.StatusLocked,
+			wantAttempts: 1,
+		},
+		{
+			name:         "5xx not classified",
+			opts:         []Option{WithResponseErrorRetryClassifier(func(*http.Response) bool { return true })},
+			status:       http.StatusNotImplemented,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) > 1 {
+					return
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(append(tt.opts, WithRetry(3, fastBackoff))...)
+			resp, err := c.Get(srv.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestWithRetryableHeader(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []Option
+		status       int
+		header       string // X-Retryable value, "" for none
+		wantAttempts int32
+	}{
+		{
+			name:         "true retries a 400",
+			opts:         []Option{WithRetryableHeader("X-Retryable")},
+			status:       http.StatusBadRequest,
+			header:       "true",
+			wantAttempts: 2,
+		},
+		{
+			name:         "false stops a 503",
+			opts:         []Option{WithRetryableHeader("X-Retryable")},
+			status:       http.StatusServiceUnavailable,
+			header:       "false",
+			wantAttempts: 1,
+		},
+		{
+			name:         "absent falls back to status",
+			opts:         []Option{WithRetryableHeader("X-Retryable")},
+			status:       http.StatusServiceUnavailable,
+			wantAttempts: 2,
+		},
+		{
+			name:         "invalid falls back to status",
+			opts:         []Option{WithRetryableHeader("X-Retryable")},
+			status:       http.StatusBadRequest,
+			header:       "maybe",
+			wantAttempts: 1,
+		},
+		{
+			name:         "ignored on a 200",
+			opts:         []Option{WithRetryableHeader("X-Retryable")},
+			status:       http.StatusOK,
+			header:       "true",
+			wantAttempts: 1,
+		},
+		{
+			name:         "ignored when not configured",
+			status:       http.StatusBadRequest,
+			header:       "true",
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) > 1 {
+					return
+				}
+				if tt.header != "" {
+					w.Header().Set("X-Retryable", tt.header)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(append(tt.opts, WithRetry(3, fastBackoff))...)
+			resp, err := c.Get(srv.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestWithRetryableErrors(t *testing.T) {
+	errRetryable := errors.New("shard moved")
+	errOther := errors.New("bad credentials")
+	tests := []struct {
+		name         string
+		opts         []Option
+		first        error
+		wantAttempts int32
+	}{
+		{
+			name:         "matching error retried",
+			opts:         []Option{WithRetryableErrors(errRetryable)},
+			first:        errRetryable,
+			wantAttempts: 2,
+		},
+		{
+			name:         "wrapped match retried",
+			opts:         []Option{WithRetryableErrors(errRetryable)},
+			first:        fmt.Errorf("dial: %w", errRetryable),
+			wantAttempts: 2,
+		},
+		{
+			name:         "unrelated error not retried",
+			opts:         []Option{WithRetryableErrors(errRetryable)},
+			first:        errOther,
+			wantAttempts: 1,
+		},
+		{
+			name:         "every error retried by default",
+			first:        errOther,
+			wantAttempts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			c := NewDefaultClient(append(tt.opts, WithRetry(3, fastBackoff))...)
+			c.client.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				if attempts.Add(1) == 1 {
+					return nil, tt.first
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+			})
+			resp, err := c.Get("http://example.test/")
+			if err == nil {
+				resp.Body.Close()
+			}
+
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+			if tt.wantAttempts == 1 && !errors.Is(err, tt.first) {
+				t.Errorf("err = %v, want %v", err, tt.first)
+			}
+		})
+	}
+}
+
+func TestWithMaxRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		maxWait    time.Duration
+		wantMin    time.Duration
+		wantMax    time.Duration
+	}{
+		{
+			name:       "huge Retry-After clamped to cap",
+			retryAfter: "86400",
+			maxWait:    50 * time.Millisecond,
+			wantMin:    50 * time.Millisecond,
+			wantMax:    time.Second,
+		},
+		{
+			name:       "HTTP date clamped to cap",
+			retryAfter: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat),
+			maxWait:    50 * time.Millisecond,
+			wantMin:    50 * time.Millisecond,
+			wantMax:    time.Second,
+		},
+		{
+			name:       "Retry-After under cap honored",
+			retryAfter: "0",
+			maxWait:    time.Hour,
+			wantMin:    0,
+			wantMax:    500 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) == 1 {
+					w.Header().Set("Retry-After", tt.retryAfter)
+					w.WriteHeader(http.StatusTooManyRequests)
+				}
+			}))
+			defer srv.Close()
+
+			// A slow backoff shows the wait came from Retry-After.
+			slow := func(int) time.Duration { return 10 * time.Second }
+			c := NewDefaultClient(WithRetry(2, slow), WithMaxRetryAfter(tt.maxWait))
+
+			start := time.Now()
+			resp, err := c.Get(srv.URL)
+			elapsed := time.Since(start)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK || attempts.Load() != 2 {
+				t.Fatalf("status = %d after %d attempts, want 200 after 2", resp.StatusCode, attempts.Load())
+			}
+			if elapsed < tt.wantMin || elapsed > tt.wantMax {
+				t.Errorf("waited %v, want between %v and %v", elapsed, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "120", want: 2 * time.Minute, wantOK: true},
+		{value: " 0 ", want: 0, wantOK: true},
+		{value: now.Add(30 * time.Second).Format(http.TimeFormat), want: 30 * time.Second, wantOK: true},
+		{value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, wantOK: true},
+		{value: "", wantOK: false},
+		{value: "-5", want: 0, wantOK: true},
+		{value: "soon", wantOK: false},
+		{value: "1.5", wantOK: false},
+		{value: "Mon, 32 Foo 2024 99:00:00 GMT", wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestWithConnectRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []Option
+		dialFailures int32
+		status       int
+		method       string
+		wantStatus   int
+		wantDials    int32
+		wantHits     int32
+		wantErr      bool
+	}{
+		{
+			name:         "dial failure retried",
+			dialFailures: 1,
+			status:       http.StatusOK,
+			method:       http.MethodPost,
+			wantStatus:   http.StatusOK,
+			wantDials:    2,
+			wantHits:     1,
+		},
+		{
+			name:       "500 not retried",
+			status:     http.StatusInternalServerError,
+			method:     http.MethodGet,
+			wantStatus: http.StatusInternalServerError,
+			wantDials:  1,
+			wantHits:   1,
+		},
+		{
+			name:         "retry budget spent",
+			opts:         []Option{WithRetryBudget(0.1, 0)},
+			dialFailures: 1,
+			status:       http.StatusOK,
+			method:       http.MethodGet,
+			wantDials:    1,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(append(tt.opts, WithConnectRetry(3, fastBackoff))...)
+			var dials atomic.Int32
+			dial := c.transport.DialContext
+			c.transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				if dials.Add(1) <= tt.dialFailures {
+					return nil, &net.OpError{Op: "dial", Net: network, Err: errors.New("connection refused")}
+				}
+				return dial(ctx, network, addr)
+			}
+
+			req, _ := http.NewRequest(tt.method, srv.URL, strings.NewReader(`{}`))
+			resp, err := c.Do(req)
+			if tt.wantErr {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatal("Do() error = nil, want dial error")
+				}
+				if got := dials.Load(); got != tt.wantDials {
+					t.Errorf("dials = %d, want %d", got, tt.wantDials)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := dials.Load(); got != tt.wantDials {
+				t.Errorf("dials = %d, want %d", got, tt.wantDials)
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("server hits = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestWithRetryOnEmptyBody(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []Option
+		first        func(w http.ResponseWriter)
+		wantAttempts int32
+		wantBody     string
+	}{
+		{
+			name:         "empty 200 retried",
+			opts:         []Option{WithRetryOnEmptyBody()},
+			first:        func(w http.ResponseWriter) { w.Header().Set("Content-Length", "0") },
+			wantAttempts: 2,
+			wantBody:     `[{"id":1}]`,
+		},
+		{
+			name: "empty chunked 200 retried",
+			opts: []Option{WithRetryOnEmptyBody()},
+			first: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusOK)
+				w.(http.Flusher).Flush()
+			},
+			wantAttempts: 2,
+			wantBody:     `[{"id":1}]`,
+		},
+		{
+			name: "chunked body with data kept intact",
+			opts: []Option{WithRetryOnEmptyBody()},
+			first: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusOK)
+				w.(http.Flusher).Flush()
+				w.Write([]byte(`[{"id":0}]`))
+			},
+			wantAttempts: 1,
+			wantBody:     `[{"id":0}]`,
+		},
+		{
+			name:         "204 not retried",
+			opts:         []Option{WithRetryOnEmptyBody()},
+			first:        func(w http.ResponseWriter) { w.WriteHeader(http.StatusNoContent) },
+			wantAttempts: 1,
+			wantBody:     "",
+		},
+		{
+			name:         "empty 200 not retried without option",
+			first:        func(w http.ResponseWriter) { w.Header().Set("Content-Length", "0") },
+			wantAttempts: 1,
+			wantBody:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) == 1 {
+					tt.first(w)
+					return
+				}
+				w.Write([]byte(`[{"id":1}]`))
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(append(tt.opts, WithRetry(3, fastBackoff))...)
+			resp, err := c.Get(srv.URL)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"io"
+	"log/slog"
+	"math/rand"
+	"mime"
+	"net/http"
+	"sync"
+)
+
+// sensitiveContentTypes are media types whose bodies are never sampled, as
+// they typically carry credentials or form input.
+var sensitiveContentTypes = map[string]bool{
+	"application/jwt":                   true,
+	"application/x-www-form-urlencoded": true,
+	"multipart/form-data":               true,
+}
+
+// WithBodySampleRate logs the first maxBytes of the response body for a
+// random fraction of requests, at debug level, once the body has been read
+// or closed. Bodies of sensitive content types such as form data and JWTs
+// are never sampled.
+func WithBodySampleRate(fraction float64, maxBytes int) Option {
+	return func(c *DefaultClient) {
+		c.sampleRate = fraction
+		c.sampleBytes = maxBytes
+	}
+}
+
+// WithSampleSource seeds the choice of sampled requests from src, making it
+// reproducible in tests. By default a time-seeded source is used.
+func WithSampleSource(src rand.Source) Option {
+	return func(c *DefaultClient) {
+		c.sampler = newSampler(src)
+	}
+}
+
+type sampler struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func newSampler(src rand.Source) *sampler {
+	return &sampler{rng: rand.New(src)}
+}
+
+// sample reports whether to sample, with probability fraction.
+func (s *sampler) sample(fraction float64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rng.Float64() < fraction
+}
+
+// sampleBody arranges for resp's body to be logged if it is sampled.
+func (c *DefaultClient) sampleBody(req *http.Request, resp *http.Response) {
+	if c.sampleRate <= 0 || c.sampleBytes <= 0 {
+		return
+	}
+	if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); sensitiveContentTypes[mt] {
+		return
+	}
+	if !c.sampler.sample(c.sampleRate) {
+		return
+	}
+	resp.Body = &sampledBody{
+		rc:  resp.Body,
+		max: c.sampleBytes,
+		log: func(snippet []byte, truncated bool) {
+			c.log().LogAttrs(req.Context(), slog.LevelDebug, "response body sample",
+				slog.String("method", req.Method),
+				slog.String("url", req.URL.String()),
+				slog.Int("status", resp.StatusCode),
+				slog.String("content_type", resp.Header.Get("Content-Type")),
+				slog.String("body", string(snippet)),
+				slog.Bool("truncated", truncated),
+			)
+		},
+	}
+}
+
+// sampledBody keeps the first max bytes read and logs them once the body is
+// read to the end, fails or is closed.
+type sampledBody struct {
+	rc        io.ReadCloser
+	max       int
+	snippet   []byte
+	truncated bool
+	log       func(snippet []byte, truncated bool)
+	once      sync.Once
+}
+
+func (b *sampledBody) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	if room := b.max - len(b.snippet); room < n {
+		b.snippet = append(b.snippet, p[:max(room, 0)]...)
+		b.truncated = true
+	} else {
+		b.snippet = append(b.snippet, p[:n]...)
+	}
+	if err != nil {
+		b.once.Do(func() { b.log(b.snippet, b.truncated) })
+	}
+	return n, err
+}
+
+func (b *sampledBody) Close() error {
+	err := b.rc.Close()
+	b.once.Do(func() { b.log(b.snippet, b.truncated) })
+	return err
+}
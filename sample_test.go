@@ -0,0 +1,108 @@
+package client
+
+import (
+	"bytes"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSampler(t *testing.T) {
+	for _, fraction := range []float64{0, 0.1, 0.5, 1} {
+		s := newSampler(rand.NewSource(1))
+		const n = 10000
+		sampled := 0
+		for range n {
+			if s.sample(fraction) {
+				sampled++
+			}
+		}
+		if got := float64(sampled) / n; got < fraction-0.02 || got > fraction+0.02 {
+			t.Errorf("fraction %v: sampled %v of requests", fraction, got)
+		}
+	}
+}
+
+func TestWithBodySampleRate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.URL.Query().Get("ct"); ct != "" {
+			w.Header().Set("Content-Type", ct)
+		}
+		w.Write([]byte("0123456789abcdef"))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name        string
+		fraction    float64
+		contentType string
+		requests    int
+		wantLogged  int
+	}{
+		{
+			name:       "always",
+			fraction:   1,
+			requests:   3,
+			wantLogged: 3,
+		},
+		{
+			name:       "never",
+			fraction:   0,
+			requests:   3,
+			wantLogged: 0,
+		},
+		{
+			name:        "sensitive content type",
+			fraction:    1,
+			contentType: "application/x-www-form-urlencoded",
+			requests:    3,
+			wantLogged:  0,
+		},
+		{
+			name:       "quarter",
+			fraction:   0.25,
+			requests:   200,
+			wantLogged: sampledCount(0.25, 200),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			c := NewDefaultClient(WithBodySampleRate(tt.fraction, 10), WithSampleSource(rand.NewSource(1)), WithLogger(logger))
+			url := srv.URL
+			if tt.contentType != "" {
+				url += "?ct=" + tt.contentType
+			}
+			for range tt.requests {
+				if body, err := FetchDataFrom(c, url); err != nil || string(body) != "0123456789abcdef" {
+					t.Fatalf("FetchDataFrom = %q, %v", body, err)
+				}
+			}
+
+			lines := strings.Count(out.String(), "response body sample")
+			if lines != tt.wantLogged {
+				t.Errorf("logged %d samples, want %d", lines, tt.wantLogged)
+			}
+			if lines > 0 && !strings.Contains(out.String(), "body=0123456789 truncated=true") {
+				t.Errorf("log = %q, want a 10-byte truncated snippet", out.String())
+			}
+		})
+	}
+}
+
+// sampledCount returns how many of n requests a sampler seeded with 1
+// samples at fraction.
+func sampledCount(fraction float64, n int) int {
+	s := newSampler(rand.NewSource(1))
+	count := 0
+	for range n {
+		if s.sample(fraction) {
+			count++
+		}
+	}
+	return count
+}
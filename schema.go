@@ -0,0 +1,84 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// ErrSchemaViolation is returned when a JSON response does not conform to
+// the schema configured with WithJSONSchema.
+var ErrSchemaViolation = errors.New("response does not match schema")
+
+// WithJSONSchema validates the body of every successful JSON response
+// against schema once it has been fully read. Responses with a non-JSON
+// Content-Type are not validated. If schema does not compile, every request
+// fails with ErrInvalidOption.
+func WithJSONSchema(schema []byte) Option {
+	compiled, err := compileSchema(schema)
+	return func(c *DefaultClient) {
+		if err != nil {
+			c.invalidOption(fmt.Errorf("JSON schema: %w", err))
+			return
+		}
+		c.schema = compiled
+	}
+}
+
+func compileSchema(schema []byte) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("response.json", bytes.NewReader(schema)); err != nil {
+		return nil, err
+	}
+	return compiler.Compile("response.json")
+}
+
+// validatesSchema reports whether resp should be checked against the schema.
+func (c *DefaultClient) validatesSchema(resp *http.Response) bool {
+	if c.schema == nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func (c *DefaultClient) validateSchema(body []byte, _ http.Header) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("%w: %v", ErrSchemaViolation, err)
+	}
+
+	err := c.schema.Validate(v)
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrSchemaViolation, strings.Join(violations(verr), "; "))
+}
+
+// violations flattens a validation error tree into one line per failure.
+func violations(verr *jsonschema.ValidationError) []string {
+	if len(verr.Causes) == 0 {
+		loc := verr.InstanceLocation
+		if loc == "" {
+			loc = "/"
+		}
+		return []string{loc + ": " + verr.Message}
+	}
+	var out []string
+	for _, cause := range verr.Causes {
+		out = append(out, violations(cause)...)
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var postSchema = []byte(`{
+	"type": "object",
+	"required": ["id", "title"],
+	"properties": {
+		"id": {"type": "integer"},
+		"title": {"type": "string"}
+	}
+}`)
+
+func TestWithJSONSchema(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantErr     string
+	}{
+		{
+			name:        "conforming payload",
+			contentType: "application/json",
+			body:        `{"id":1,"title":"hello"}`,
+		},
+		{
+			name:        "missing required field",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"id":1}`,
+			wantErr:     "missing properties: 'title'",
+		},
+		{
+			name:        "wrong type",
+			contentType: "application/problem+json",
+			body:        `{"id":"one","title":"hello"}`,
+			wantErr:     "/id: expected integer, but got string",
+		},
+		{
+			name:        "non-JSON response skipped",
+			contentType: "text/plain",
+			body:        `not json`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(WithJSONSchema(postSchema))
+			got, err := FetchDataFrom(c, srv.URL)
+
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrSchemaViolation) {
+					t.Fatalf("FetchDataFrom() error = %v, want ErrSchemaViolation", err)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("FetchDataFrom() error = %q, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchDataFrom() unexpected error = %v", err)
+			}
+			if string(got) != tt.body {
+				t.Errorf("FetchDataFrom() = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestWithJSONSchema_InvalidSchema(t *testing.T) {
+	c := NewDefaultClient(WithJSONSchema([]byte(`{"type": 12}`)))
+	if _, err := c.Get("http://127.0.0.1:1/"); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Get() error = %v, want ErrInvalidOption", err)
+	}
+}
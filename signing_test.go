@@ -0,0 +1,118 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+func TestWithRequestSigning(t *testing.T) {
+	clock := fixedClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	secret := []byte("signing-secret")
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		want   string
+	}{
+		{
+			name:   "GET without body",
+			method: http.MethodGet,
+			want:   "69eb8010316540fd95d54a25df206a1ee336f6f89822394074ea2d1e3a5e58dc",
+		},
+		{
+			name:   "POST with body",
+			method: http.MethodPost,
+			body:   `{"title":"hello"}`,
+			want:   "799f05d85a07399ce5e846c4608a72bc0d860c7bca7177b2958a10e626cf7b58",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got http.Header
+			var gotBody string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Clone()
+				b, _ := io.ReadAll(r.Body)
+				gotBody = string(b)
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(WithClock(clock), WithRequestSigning("key-1", secret))
+			var signatures []string
+			for i := 0; i < 2; i++ {
+				req, _ := http.NewRequest(tt.method, srv.URL+"/posts?page=2", strings.NewReader(tt.body))
+				resp, err := c.Do(req)
+				if err != nil {
+					t.Fatalf("Do() error = %v", err)
+				}
+				resp.Body.Close()
+				signatures = append(signatures, got.Get(SignatureHeader))
+			}
+
+			if signatures[0] != signatures[1] {
+				t.Errorf("signatures differ across runs: %q vs %q", signatures[0], signatures[1])
+			}
+			if signatures[0] != tt.want {
+				t.Errorf("%s = %q, want %q", SignatureHeader, signatures[0], tt.want)
+			}
+			if ts := got.Get(SignatureTimestampHeader); ts != "1714564800" {
+				t.Errorf("%s = %q, want 1714564800", SignatureTimestampHeader, ts)
+			}
+			if id := got.Get(SignatureKeyIDHeader); id != "key-1" {
+				t.Errorf("%s = %q, want key-1", SignatureKeyIDHeader, id)
+			}
+			if gotBody != tt.body {
+				t.Errorf("server body = %q, want %q", gotBody, tt.body)
+			}
+		})
+	}
+}
+
+func TestWithRequestSigning_StreamedBody(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{
+			name: "chunked upload",
+			opts: []Option{WithChunkedUpload()},
+		},
+		{
+			name: "streaming compression",
+			opts: []Option{WithRequestCompression(4)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+			}))
+			defer srv.Close()
+
+			opts := append([]Option{WithRequestSigning("key-1", []byte("signing-secret"))}, tt.opts...)
+			c := NewDefaultClient(opts...)
+			body := io.NopCloser(strings.NewReader(`{"title":"hello"}`))
+			req, _ := http.NewRequest(http.MethodPost, srv.URL, body)
+			if _, err := c.Do(req); !errors.Is(err, ErrUnsignableBody) {
+				t.Fatalf("Do() error = %v, want ErrUnsignableBody", err)
+			}
+			if got := hits.Load(); got != 0 {
+				t.Errorf("server hits = %d, want 0", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package client
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Event is a single server-sent event.
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// StreamSSE reads the text/event-stream at url and calls fn for every event
+// as it arrives. Streaming stops at the end of the stream, when fn returns
+// an error, or when ctx is done.
+func StreamSSE(ctx context.Context, client HTTPClient, url string, fn func(Event) error) error {
+	if err := streamSSE(ctx, client, url, fn); err != nil {
+		return mapError(client, err)
+	}
+	return nil
+}
+
+// FetchNDJSON reads the newline-delimited JSON stream at url, decoding each
+// line into a T and calling fn with it as it arrives. Blank lines are skipped.
+func FetchNDJSON[T any](ctx context.Context, client HTTPClient, url string, fn func(T) error) error {
+	if err := fetchNDJSON(ctx, client, url, fn); err != nil {
+		return mapError(client, err)
+	}
+	return nil
+}
+
+// FetchProgressive reads the body at url and calls chunk with its bytes as
+// they arrive, without buffering the whole response. The slice passed to
+// chunk is only valid until it returns. Reading stops at the end of the body,
+// when chunk returns an error, or when ctx is done.
+func FetchProgressive(ctx context.Context, client HTTPClient, url string, chunk func([]byte) error) error {
+	if err := fetchProgressive(ctx, client, url, chunk); err != nil {
+		return mapError(client, err)
+	}
+	return nil
+}
+
+func streamSSE(ctx context.Context, client HTTPClient, url string, fn func(Event) error) error {
+	body, err := openStream(ctx, client, url, "text/event-stream")
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	var (
+		ev      Event
+		data    []string
+		records int
+	)
+	defer func() { countStream(ctx, client, "sse", records) }()
+	scanner := newLineScanner(client, body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if data != nil {
+				ev.Data = strings.Join(data, "\n")
+				if err := fn(ev); err != nil {
+					return err
+				}
+				records++
+			}
+			ev, data = Event{ID: ev.ID}, nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			data = append(data, value)
+		case "event":
+			ev.Event = value
+		case "id":
+			ev.ID = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read event stream: %w", err)
+	}
+	return nil
+}
+
+func fetchNDJSON[T any](ctx context.Context, client HTTPClient, url string, fn func(T) error) error {
+	body, err := openStream(ctx, client, url, "application/x-ndjson")
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	records := 0
+	defer func() { countStream(ctx, client, "ndjson", records) }()
+	scanner := newLineScanner(client, body)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var v T
+		if err := json.Unmarshal(scanner.Bytes(), &v); err != nil {
+			return fmt.Errorf("failed to decode line %d: %w", line, err)
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+		records++
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read ndjson stream: %w", err)
+	}
+	return nil
+}
+
+func fetchProgressive(ctx context.Context, client HTTPClient, url string, chunk func([]byte) error) error {
+	body, err := openStream(ctx, client, url, "*/*")
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	stop := context.AfterFunc(ctx, func() { body.Close() })
+	defer stop()
+
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			if err := chunk(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			return fmt.Errorf("failed to read response body: %w", err)
+		}
+	}
+}
+
+// ErrLineTooLong is returned when a line of an SSE or NDJSON stream is
+// longer than the limit set with WithMaxLineBytes.
+var ErrLineTooLong = errors.New("stream line too long")
+
+// WithMaxLineBytes bounds the length of a single line in the StreamSSE and
+// FetchNDJSON decoders to n bytes, so a server cannot make them buffer an
+// unbounded line. Longer lines fail the stream with ErrLineTooLong. The
+// default limit is 64 KiB.
+func WithMaxLineBytes(n int) Option {
+	return func(c *DefaultClient) {
+		c.maxLineBytes = n
+	}
+}
+
+// lineLimiter is implemented by clients that bound streamed line lengths.
+type lineLimiter interface {
+	maxLineLength() int
+}
+
+func (c *DefaultClient) maxLineLength() int {
+	return c.maxLineBytes
+}
+
+// lineScanner is a bufio.Scanner over lines of at most max bytes, excluding
+// the line ending.
+type lineScanner struct {
+	*bufio.Scanner
+	max int
+	err error
+}
+
+func newLineScanner(client HTTPClient, r io.Reader) *lineScanner {
+	n := bufio.MaxScanTokenSize
+	if ll, ok := client.(lineLimiter); ok && ll.maxLineLength() > 0 {
+		n = ll.maxLineLength()
+	}
+	s := &lineScanner{Scanner: bufio.NewScanner(r), max: n}
+	// Leave room for a CRLF, which the scanner needs to see to end a line.
+	s.Buffer(make([]byte, 0, min(n+2, 4096)), n+2)
+	return s
+}
+
+func (s *lineScanner) Scan() bool {
+	if !s.Scanner.Scan() {
+		if errors.Is(s.Scanner.Err(), bufio.ErrTooLong) {
+			s.err = s.tooLong()
+		}
+		return false
+	}
+	if len(s.Bytes()) > s.max {
+		s.err = s.tooLong()
+		return false
+	}
+	return true
+}
+
+func (s *lineScanner) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.Scanner.Err()
+}
+
+func (s *lineScanner) tooLong() error {
+	return fmt.Errorf("%w: limit is %d bytes", ErrLineTooLong, s.max)
+}
+
+// streamCounter is implemented by clients that count streamed records.
+type streamCounter interface {
+	countStream(ctx context.Context, format string, records int)
+}
+
+func countStream(ctx context.Context, client HTTPClient, format string, records int) {
+	if sc, ok := client.(streamCounter); ok {
+		sc.countStream(ctx, format, records)
+	}
+}
+
+func (c *DefaultClient) countStream(ctx context.Context, format string, records int) {
+	if c.metrics != nil {
+		c.metrics.Add(MetricStreamRecords, float64(records), map[string]string{
+			"format":    format,
+			"operation": operationLabel(ctx),
+		})
+	}
+}
+
+// openStream issues a streaming GET for url and returns its body, decoded
+// on the fly when the server compressed it.
+func openStream(ctx context.Context, client HTTPClient, url, accept string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", accept)
+
+	resp, err := send(client, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch data: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, statusError(client, resp)
+	}
+
+	body := resp.Body
+	if rb, ok := client.(rawBodier); !ok || !rb.rawBodyEnabled() {
+		if body, err = decodeBody(resp); err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+	}
+	if bw, ok := client.(bodyWrapper); ok {
+		body = bw.wrapBody(body)
+	}
+	return body, nil
+}
+
+// WithBodyReaderWrapper decorates the body reader of the streaming helpers
+// (StreamSSE, FetchNDJSON and FetchProgressive) with wrap, after any
+// decompression, e.g. to count lines or decode a custom encoding. Wrappers
+// compose in the order given, each reading from the previous one. Closing
+// still closes the underlying body.
+func WithBodyReaderWrapper(wrap func(io.Reader) io.Reader) Option {
+	return func(c *DefaultClient) {
+		c.bodyWrappers = append(c.bodyWrappers, wrap)
+	}
+}
+
+// bodyWrapper is implemented by clients that decorate streamed bodies.
+type bodyWrapper interface {
+	wrapBody(body io.ReadCloser) io.ReadCloser
+}
+
+func (c *DefaultClient) wrapBody(body io.ReadCloser) io.ReadCloser {
+	if len(c.bodyWrappers) == 0 {
+		return body
+	}
+	var r io.Reader = body
+	for _, wrap := range c.bodyWrappers {
+		r = wrap(r)
+	}
+	return &wrappedBody{Reader: r, rc: body}
+}
+
+type wrappedBody struct {
+	io.Reader
+	rc io.ReadCloser
+}
+
+func (b *wrappedBody) Close() error {
+	return b.rc.Close()
+}
+
+// decodeBody wraps resp.Body in a streaming decompressor when the transport
+// has not already decoded it.
+func decodeBody(resp *http.Response) (io.ReadCloser, error) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return resp.Body, nil
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &decodedBody{Reader: zr, decoder: zr, body: resp.Body}, nil
+}
+
+type decodedBody struct {
+	io.Reader
+	decoder io.Closer
+	body    io.Closer
+}
+
+func (d *decodedBody) Close() error {
+	d.decoder.Close()
+	return d.body.Close()
+}
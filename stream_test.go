@@ -0,0 +1,430 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gzipStream returns a mock client serving a gzip-compressed body whose
+// chunks are written one at a time: each chunk after the first is only sent
+// once the previous one has been acknowledged on ack.
+func gzipStream(t *testing.T, chunks []string, ack <-chan struct{}) HTTPClient {
+	t.Helper()
+	return &mockHTTPClient{
+		doFunc: func(url string) (*http.Response, error) {
+			pr, pw := io.Pipe()
+			go func() {
+				zw := gzip.NewWriter(pw)
+				for i, chunk := range chunks {
+					if i > 0 {
+						select {
+						case <-ack:
+						case <-time.After(2 * time.Second):
+							pw.CloseWithError(errors.New("chunk was not consumed incrementally"))
+							return
+						}
+					}
+					zw.Write([]byte(chunk))
+					zw.Flush()
+				}
+				zw.Close()
+				pw.Close()
+			}()
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       pr,
+				Header:     make(http.Header),
+			}
+			resp.Header.Set("Content-Encoding", "gzip")
+			return resp, nil
+		},
+	}
+}
+
+func TestStreamSSE(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept"); got != "text/event-stream" {
+			t.Errorf("Accept = %q, want text/event-stream", got)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(": comment\n\nid: 1\nevent: post\ndata: {\"id\":1}\n\ndata: line one\ndata: line two\n\n"))
+	}))
+	defer srv.Close()
+
+	var got []Event
+	err := StreamSSE(context.Background(), NewDefaultClient(), srv.URL, func(ev Event) error {
+		got = append(got, ev)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamSSE() error = %v", err)
+	}
+
+	want := []Event{
+		{ID: "1", Event: "post", Data: `{"id":1}`},
+		{ID: "1", Data: "line one\nline two"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("StreamSSE() events = %+v, want %+v", got, want)
+	}
+}
+
+func TestStreamSSE_Gzip(t *testing.T) {
+	ack := make(chan struct{}, 2)
+	client := gzipStream(t, []string{"data: first\n\n", "data: second\n\n"}, ack)
+
+	var got []string
+	err := StreamSSE(context.Background(), client, Endpoint, func(ev Event) error {
+		got = append(got, ev.Data)
+		ack <- struct{}{}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamSSE() error = %v", err)
+	}
+	if want := []string{"first", "second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("StreamSSE() data = %v, want %v", got, want)
+	}
+}
+
+type streamPost struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func TestFetchNDJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{\"id\":1,\"title\":\"a\"}\n\n{\"id\":2,\"title\":\"b\"}\n"))
+	}))
+	defer srv.Close()
+
+	var got []streamPost
+	err := FetchNDJSON(context.Background(), NewDefaultClient(), srv.URL, func(p streamPost) error {
+		got = append(got, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("FetchNDJSON() error = %v", err)
+	}
+	want := []streamPost{{ID: 1, Title: "a"}, {ID: 2, Title: "b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FetchNDJSON() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFetchNDJSON_Gzip(t *testing.T) {
+	ack := make(chan struct{}, 2)
+	client := gzipStream(t, []string{"{\"id\":1}\n", "{\"id\":2}\n"}, ack)
+
+	var got []int
+	err := FetchNDJSON(context.Background(), client, Endpoint, func(p streamPost) error {
+		got = append(got, p.ID)
+		ack <- struct{}{}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("FetchNDJSON() error = %v", err)
+	}
+	if want := []int{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FetchNDJSON() ids = %v, want %v", got, want)
+	}
+}
+
+func TestFetchNDJSON_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("{\"id\":1}\n{\"id\":\n"))
+	}))
+	defer srv.Close()
+
+	noop := func(streamPost) error { return nil }
+
+	err := FetchNDJSON(context.Background(), NewDefaultClient(), srv.URL+"/missing", noop)
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Errorf("FetchNDJSON() error = %v, want HTTPError 404", err)
+	}
+
+	err = FetchNDJSON(context.Background(), NewDefaultClient(), srv.URL, noop)
+	if err == nil {
+		t.Error("FetchNDJSON() expected decode error for malformed line")
+	}
+}
+
+func TestFetchProgressive(t *testing.T) {
+	firstSeen := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `[{"id":1},`)
+		w.(http.Flusher).Flush()
+		select {
+		case <-firstSeen:
+		case <-time.After(5 * time.Second):
+			t.Error("first chunk was not delivered before the body completed")
+		}
+		io.WriteString(w, `{"id":2}]`)
+	}))
+	defer srv.Close()
+
+	var got []string
+	err := FetchProgressive(context.Background(), NewDefaultClient(), srv.URL, func(b []byte) error {
+		if len(got) == 0 {
+			close(firstSeen)
+		}
+		got = append(got, string(b))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("FetchProgressive() error = %v", err)
+	}
+	want := []string{`[{"id":1},`, `{"id":2}]`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("chunks = %q, want %q", got, want)
+	}
+}
+
+func TestFetchProgressive_Stops(t *testing.T) {
+	errStop := errors.New("stop")
+	tests := []struct {
+		name    string
+		cancel  bool
+		chunkFn func() error
+		wantErr error
+	}{
+		{
+			name:    "callback error",
+			chunkFn: func() error { return errStop },
+			wantErr: errStop,
+		},
+		{
+			name:    "context cancelled",
+			cancel:  true,
+			chunkFn: func() error { return nil },
+			wantErr: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, "chunk")
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+			}))
+			defer srv.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			calls := 0
+			err := FetchProgressive(ctx, NewDefaultClient(), srv.URL, func([]byte) error {
+				calls++
+				if tt.cancel {
+					cancel()
+				}
+				return tt.chunkFn()
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if calls != 1 {
+				t.Errorf("chunk calls = %d, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestStreamRecordsMetric(t *testing.T) {
+	errStop := errors.New("stop")
+	tests := []struct {
+		name   string
+		format string
+		body   string
+		stream func(ctx context.Context, c HTTPClient, url string) error
+		want   float64
+	}{
+		{
+			name:   "ndjson",
+			format: "ndjson",
+			body:   "{\"id\":1}\n{\"id\":2}\n\n{\"id\":3}\n",
+			stream: func(ctx context.Context, c HTTPClient, url string) error {
+				return FetchNDJSON(ctx, c, url, func(streamPost) error { return nil })
+			},
+			want: 3,
+		},
+		{
+			name:   "sse",
+			format: "sse",
+			body:   "data: a\n\n: keep-alive\n\ndata: b\n\ndata: c\n\ndata: d\n\n",
+			stream: func(ctx context.Context, c HTTPClient, url string) error {
+				return StreamSSE(ctx, c, url, func(Event) error { return nil })
+			},
+			want: 4,
+		},
+		{
+			name:   "stopped early",
+			format: "ndjson",
+			body:   "{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n",
+			stream: func(ctx context.Context, c HTTPClient, url string) error {
+				return FetchNDJSON(ctx, c, url, func(p streamPost) error {
+					if p.ID == 3 {
+						return errStop
+					}
+					return nil
+				})
+			},
+			want: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			metrics := newRecordingMetrics()
+			ctx := WithOperationLabel(context.Background(), "feed")
+			if err := tt.stream(ctx, NewDefaultClient(WithMetrics(metrics)), srv.URL); err != nil && !errors.Is(err, errStop) {
+				t.Fatalf("stream() error = %v", err)
+			}
+			labels := map[string]string{"format": tt.format, "operation": "feed"}
+			if got := sumValues(metrics.values(MetricStreamRecords, labels)); got != tt.want {
+				t.Errorf("records = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// upperReader upper-cases ASCII letters read from r.
+type upperReader struct{ r io.Reader }
+
+func (u upperReader) Read(p []byte) (int, error) {
+	n, err := u.r.Read(p)
+	copy(p, bytes.ToUpper(p[:n]))
+	return n, err
+}
+
+func TestWithBodyReaderWrapper(t *testing.T) {
+	closed := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello, world")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		close(closed)
+	}))
+	defer srv.Close()
+
+	var seen bytes.Buffer
+	c := NewDefaultClient(
+		WithBodyReaderWrapper(func(r io.Reader) io.Reader { return upperReader{r} }),
+		WithBodyReaderWrapper(func(r io.Reader) io.Reader { return io.TeeReader(r, &seen) }),
+	)
+	errStop := errors.New("stop")
+	var got string
+	err := FetchProgressive(context.Background(), c, srv.URL, func(b []byte) error {
+		got = string(b)
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("err = %v, want %v", err, errStop)
+	}
+	if got != "HELLO, WORLD" {
+		t.Errorf("chunk = %q, want it upper-cased", got)
+	}
+	if seen.String() != "HELLO, WORLD" {
+		t.Errorf("second wrapper saw %q, want the first wrapper's output", seen.String())
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Error("underlying body was not closed")
+	}
+}
+
+func TestWithMaxLineBytes(t *testing.T) {
+	long := `{"id":1,"title":"` + strings.Repeat("x", 100) + `"}`
+	tests := []struct {
+		name    string
+		format  string
+		body    string
+		opts    []Option
+		want    int // records delivered
+		wantErr error
+	}{
+		{
+			name:   "ndjson within limit",
+			format: "ndjson",
+			body:   "{\"id\":1}\r\n{\"id\":2}\n",
+			opts:   []Option{WithMaxLineBytes(8)},
+			want:   2,
+		},
+		{
+			name:    "ndjson line too long",
+			format:  "ndjson",
+			body:    "{\"id\":1}\n" + long + "\n",
+			opts:    []Option{WithMaxLineBytes(64)},
+			want:    1,
+			wantErr: ErrLineTooLong,
+		},
+		{
+			name:    "ndjson unterminated line too long",
+			format:  "ndjson",
+			body:    long,
+			opts:    []Option{WithMaxLineBytes(64)},
+			wantErr: ErrLineTooLong,
+		},
+		{
+			name:   "ndjson default limit",
+			format: "ndjson",
+			body:   long + "\n",
+			want:   1,
+		},
+		{
+			name:   "sse within limit",
+			format: "sse",
+			body:   "data: hello\n\n",
+			opts:   []Option{WithMaxLineBytes(64)},
+			want:   1,
+		},
+		{
+			name:    "sse line too long",
+			format:  "sse",
+			body:    "data: " + long + "\n\n",
+			opts:    []Option{WithMaxLineBytes(64)},
+			wantErr: ErrLineTooLong,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(tt.opts...)
+			got := 0
+			var err error
+			if tt.format == "sse" {
+				err = StreamSSE(context.Background(), c, srv.URL, func(Event) error { got++; return nil })
+			} else {
+				err = FetchNDJSON(context.Background(), c, srv.URL, func(streamPost) error { got++; return nil })
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("delivered %d records, want %d", got, tt.want)
+			}
+		})
+	}
+}
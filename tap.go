@@ -0,0 +1,63 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// WithResponseTap copies every response body to w as it is read, for audit
+// logging, without changing the bytes returned to the caller. It applies to
+// the streaming helpers too. Concurrent requests write to w concurrently.
+func WithResponseTap(w io.Writer) Option {
+	return func(c *DefaultClient) {
+		c.responseTap = w
+	}
+}
+
+type tapBody struct {
+	io.Reader
+	rc io.ReadCloser
+}
+
+func (b *tapBody) Close() error {
+	return b.rc.Close()
+}
+
+// WithRequestTap writes a copy of every outbound request body to w. The body
+// is buffered so it stays replayable for retries. redact, when non-nil,
+// filters the copy written to w (e.g. to mask secrets); the bytes sent to
+// the server are unchanged.
+func WithRequestTap(w io.Writer, redact func([]byte) []byte) Option {
+	return func(c *DefaultClient) {
+		c.requestTap = w
+		c.requestTapRedact = redact
+	}
+}
+
+func (c *DefaultClient) tapRequest(req *http.Request) (*http.Request, error) {
+	if c.requestTap == nil || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	copied := body
+	if c.requestTapRedact != nil {
+		copied = c.requestTapRedact(bytes.Clone(body))
+	}
+	c.requestTap.Write(copied)
+
+	req = req.Clone(req.Context())
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return req, nil
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithResponseTap(t *testing.T) {
+	const payload = `[{"userId":1,"id":1,"title":"tapped"}]`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(payload))
+	}))
+	defer srv.Close()
+
+	var tap bytes.Buffer
+	c := NewDefaultClient(WithResponseTap(&tap))
+
+	body, err := FetchDataFrom(c, srv.URL)
+	if err != nil {
+		t.Fatalf("FetchDataFrom() error = %v", err)
+	}
+	if string(body) != payload {
+		t.Errorf("FetchDataFrom() = %q, want %q", body, payload)
+	}
+	if tap.String() != payload {
+		t.Errorf("tap = %q, want %q", tap.String(), payload)
+	}
+}
+
+func TestWithResponseTap_Streaming(t *testing.T) {
+	const payload = "{\"id\":1}\n{\"id\":2}\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(payload))
+	}))
+	defer srv.Close()
+
+	var tap bytes.Buffer
+	c := NewDefaultClient(WithResponseTap(&tap))
+
+	var n int
+	err := FetchNDJSON(context.Background(), c, srv.URL, func(p streamPost) error {
+		n++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("FetchNDJSON() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("records = %d, want 2", n)
+	}
+	if tap.String() != payload {
+		t.Errorf("tap = %q, want %q", tap.String(), payload)
+	}
+}
+
+func TestWithRequestTap(t *testing.T) {
+	const payload = `{"title":"foo","body":"bar","userId":1,"password":"hunter2"}`
+
+	tests := []struct {
+		name    string
+		redact  func([]byte) []byte
+		wantTap string
+	}{
+		{
+			name:    "captures outbound body",
+			wantTap: payload,
+		},
+		{
+			name: "redacts captured copy",
+			redact: func(b []byte) []byte {
+				return bytes.ReplaceAll(b, []byte("hunter2"), []byte("[REDACTED]"))
+			},
+			wantTap: `{"title":"foo","body":"bar","userId":1,"password":"[REDACTED]"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				received = string(b)
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer srv.Close()
+
+			var tap bytes.Buffer
+			c := NewDefaultClient(WithRequestTap(&tap, tt.redact))
+
+			req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := c.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if tap.String() != tt.wantTap {
+				t.Errorf("tap = %q, want %q", tap.String(), tt.wantTap)
+			}
+			if received != payload {
+				t.Errorf("server received %q, want %q", received, payload)
+			}
+		})
+	}
+}
+
+func TestWithRequestTap_Replayable(t *testing.T) {
+	const payload = `{"id":1}`
+
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	var tap bytes.Buffer
+	c := NewDefaultClient(WithRequestTap(&tap, nil), WithRetry(2, fastBackoff))
+
+	// io.NopCloser hides the reader type, so the request has no GetBody of
+	// its own and only the tap's buffering makes it replayable.
+	req, err := http.NewRequest(http.MethodPut, srv.URL, io.NopCloser(strings.NewReader(payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if len(bodies) != 2 || bodies[0] != payload || bodies[1] != payload {
+		t.Errorf("server bodies = %q, want payload sent twice", bodies)
+	}
+	if tap.String() != payload {
+		t.Errorf("tap = %q, want %q captured once", tap.String(), payload)
+	}
+}
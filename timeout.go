@@ -0,0 +1,303 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ErrPerTryTimeout is returned when a single try exceeds the limit set with
+// WithPerTryTimeout.
+var ErrPerTryTimeout = errors.New("per-try timeout exceeded")
+
+// ErrRedirectTimeExceeded is returned when following redirects takes longer
+// than the budget set with WithMaxRedirectTime.
+var ErrRedirectTimeExceeded = errors.New("redirect time budget exceeded")
+
+// maxRedirects matches the net/http default redirect limit.
+const maxRedirects = 10
+
+// WithTimeout bounds the whole request, including redirects, retries and
+// reading the body.
+func WithTimeout(d time.Duration) Option {
+	return func(c *DefaultClient) {
+		c.timeout = d
+	}
+}
+
+// WithPerTryTimeout bounds each try. The deadline is reset for every
+// redirect hop, so a chain of slow-but-healthy hops succeeds as long as each
+// hop is under d; use WithTimeout to bound the total.
+func WithPerTryTimeout(d time.Duration) Option {
+	return func(c *DefaultClient) {
+		c.perTryTimeout = d
+	}
+}
+
+// WithMaxRedirectTime bounds the cumulative time spent following a try's
+// redirects, counted from when the try started so the first hop is
+// included, failing the request with ErrRedirectTimeExceeded. The budget is
+// checked before each further hop.
+func WithMaxRedirectTime(d time.Duration) Option {
+	return func(c *DefaultClient) {
+		c.maxRedirectTime = d
+	}
+}
+
+type hopTimerKey struct{}
+
+// redirectStartKey holds the time.Time a try started.
+type redirectStartKey struct{}
+
+func (c *DefaultClient) withTimeout(req *http.Request) (*http.Request, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return req, func() {}
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), c.timeout)
+	return req.WithContext(ctx), cancel
+}
+
+// tryOnce sends a single try of req, enforcing the per-try timeout. The
+// try's connection is tracked on its own, so hedged tries each count
+// against the pool until their response is done with and report their own
+// remote address.
+func (c *DefaultClient) tryOnce(req *http.Request) (*http.Response, error) {
+	req, release := c.trackPool(req)
+	req, reportAddr := trackRemoteAddr(req)
+	resp, err := c.sendTry(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	reportAddr(resp)
+	if c.pool != nil {
+		resp.Body = &releaseBody{rc: resp.Body, release: release}
+	}
+	return resp, nil
+}
+
+func (c *DefaultClient) sendTry(req *http.Request) (*http.Response, error) {
+	req = c.replayable(req)
+	if c.maxRedirectTime > 0 {
+		req = req.WithContext(context.WithValue(req.Context(), redirectStartKey{}, time.Now()))
+	}
+	if c.perTryTimeout <= 0 {
+		return c.sendWatched(c.propagateDeadline(req, time.Time{}))
+	}
+
+	ctx, cancel := context.WithCancelCause(req.Context())
+	timer := time.AfterFunc(c.perTryTimeout, func() { cancel(ErrPerTryTimeout) })
+	ctx = context.WithValue(ctx, hopTimerKey{}, timer)
+	stop := func() {
+		timer.Stop()
+		cancel(nil)
+	}
+
+	// The header is computed once the try's deadline is known, so it
+	// never promises the server more time than the try has.
+	req = c.propagateDeadline(req.WithContext(ctx), time.Now().Add(c.perTryTimeout))
+	resp, err := c.sendWatched(req)
+	if err != nil {
+		if errors.Is(context.Cause(ctx), ErrPerTryTimeout) {
+			err = fmt.Errorf("%w: %w", ErrPerTryTimeout, err)
+		}
+		stop()
+		return nil, err
+	}
+	resp.Body = &cancelBody{rc: resp.Body, cancel: stop}
+	return resp, nil
+}
+
+func (c *DefaultClient) checkRedirect(req *http.Request, via []*http.Request) error {
+	if c.noRedirects {
+		return http.ErrUseLastResponse
+	}
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if start, ok := req.Context().Value(redirectStartKey{}).(time.Time); ok {
+		if elapsed := time.Since(start); elapsed > c.maxRedirectTime {
+			return fmt.Errorf("%w: %v following %d redirects", ErrRedirectTimeExceeded, elapsed.Round(time.Millisecond), len(via))
+		}
+	}
+	if timer, ok := req.Context().Value(hopTimerKey{}).(*time.Timer); ok {
+		timer.Reset(c.perTryTimeout)
+	}
+	return nil
+}
+
+// cancelBody releases a request's context once its body is closed.
+type cancelBody struct {
+	rc     io.ReadCloser
+	cancel func()
+}
+
+func (b *cancelBody) Read(p []byte) (int, error) {
+	return b.rc.Read(p)
+}
+
+func (b *cancelBody) Close() error {
+	err := b.rc.Close()
+	b.cancel()
+	return err
+}
+
+// ErrBodyReadTimeout is returned when reading a response body takes longer
+// than the limit set with WithBodyReadTimeout.
+var ErrBodyReadTimeout = errors.New("response body read timeout")
+
+// WithBodyReadTimeout bounds the time spent reading a response body once
+// its headers have arrived, catching servers that trickle the body.
+func WithBodyReadTimeout(d time.Duration) Option {
+	return func(c *DefaultClient) {
+		c.bodyReadTimeout = d
+	}
+}
+
+// deadlineBody fails reads with ErrBodyReadTimeout once its timer fires,
+// closing the underlying body to unblock any read in progress.
+type deadlineBody struct {
+	rc      io.ReadCloser
+	timer   *time.Timer
+	expired atomic.Bool
+}
+
+func newDeadlineBody(rc io.ReadCloser, d time.Duration) *deadlineBody {
+	b := &deadlineBody{rc: rc}
+	b.timer = time.AfterFunc(d, func() {
+		b.expired.Store(true)
+		rc.Close()
+	})
+	return b
+}
+
+func (b *deadlineBody) Read(p []byte) (int, error) {
+	if b.expired.Load() {
+		return 0, ErrBodyReadTimeout
+	}
+	n, err := b.rc.Read(p)
+	if err != nil && b.expired.Load() {
+		return n, ErrBodyReadTimeout
+	}
+	return n, err
+}
+
+func (b *deadlineBody) Close() error {
+	b.timer.Stop()
+	return b.rc.Close()
+}
+
+// ErrStallTimeout is returned when a response body delivers no bytes for
+// the limit set with WithStallTimeout.
+var ErrStallTimeout = errors.New("response body stalled")
+
+// WithStallTimeout fails a response body read that waits longer than d for
+// the next bytes to arrive. Unlike WithBodyReadTimeout it bounds the gaps
+// between bursts rather than the whole body, so a slow but steady stream of
+// any length is allowed. Time the caller spends between reads is not
+// counted.
+func WithStallTimeout(d time.Duration) Option {
+	return func(c *DefaultClient) {
+		c.stallTimeout = d
+	}
+}
+
+// stallBody closes the underlying body when a single read waits longer
+// than d, failing it with ErrStallTimeout.
+type stallBody struct {
+	rc      io.ReadCloser
+	d       time.Duration
+	timer   *time.Timer
+	expired atomic.Bool
+}
+
+func newStallBody(rc io.ReadCloser, d time.Duration) *stallBody {
+	b := &stallBody{rc: rc, d: d}
+	b.timer = time.AfterFunc(time.Hour, func() {
+		b.expired.Store(true)
+		rc.Close()
+	})
+	b.timer.Stop()
+	return b
+}
+
+func (b *stallBody) Read(p []byte) (int, error) {
+	if b.expired.Load() {
+		return 0, ErrStallTimeout
+	}
+	b.timer.Reset(b.d)
+	n, err := b.rc.Read(p)
+	b.timer.Stop()
+	if err != nil && b.expired.Load() {
+		return n, ErrStallTimeout
+	}
+	return n, err
+}
+
+func (b *stallBody) Close() error {
+	b.timer.Stop()
+	return b.rc.Close()
+}
+
+// ErrFirstByteTimeout is returned when a response body does not start
+// within the limit set with WithFirstByteTimeout.
+var ErrFirstByteTimeout = errors.New("response first byte timeout")
+
+// WithFirstByteTimeout bounds how long reading a response's body waits for
+// its first byte, catching servers that send headers and then stall. Only
+// time spent blocked in Read counts, so a caller that is slow to start
+// reading is not mistaken for a stalled server.
+func WithFirstByteTimeout(d time.Duration) Option {
+	return func(c *DefaultClient) {
+		c.firstByteTimeout = d
+	}
+}
+
+// sendWatched sends req, enforcing the first byte timeout.
+func (c *DefaultClient) sendWatched(req *http.Request) (*http.Response, error) {
+	if c.firstByteTimeout <= 0 {
+		return c.sendOnce(req)
+	}
+
+	ctx, cancel := context.WithCancelCause(req.Context())
+	resp, err := c.sendOnce(req.WithContext(ctx))
+	if err != nil {
+		cancel(nil)
+		return nil, err
+	}
+	resp.Body = &firstByteBody{rc: resp.Body, ctx: ctx, d: c.firstByteTimeout, cancel: cancel}
+	return resp, nil
+}
+
+// firstByteBody cancels its request when a Read waits longer than d for
+// the first byte of the body.
+type firstByteBody struct {
+	rc      io.ReadCloser
+	ctx     context.Context
+	d       time.Duration
+	cancel  context.CancelCauseFunc
+	started bool
+}
+
+func (b *firstByteBody) Read(p []byte) (int, error) {
+	if b.started {
+		return b.rc.Read(p)
+	}
+	timer := time.AfterFunc(b.d, func() { b.cancel(ErrFirstByteTimeout) })
+	n, err := b.rc.Read(p)
+	timer.Stop()
+	b.started = n > 0 || err != nil
+	if err != nil && errors.Is(context.Cause(b.ctx), ErrFirstByteTimeout) {
+		err = fmt.Errorf("%w: %w", ErrFirstByteTimeout, err)
+	}
+	return n, err
+}
+
+func (b *firstByteBody) Close() error {
+	b.cancel(nil)
+	return b.rc.Close()
+}
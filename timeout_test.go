@@ -0,0 +1,355 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// slowRedirectChain serves /hop/0 .. /hop/n-1, each sleeping delay before
+// redirecting to the next, and /hop/n with the final body.
+func slowRedirectChain(t *testing.T, hops int, delay time.Duration) *httptest.Server {
+	t.Helper()
+	return redirectChain(t, hops, func(int) time.Duration { return delay })
+}
+
+// redirectChain is like slowRedirectChain with a delay chosen per hop.
+func redirectChain(t *testing.T, hops int, delay func(hop int) time.Duration) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		fmt.Sscanf(r.URL.Path, "/hop/%d", &n)
+		select {
+		case <-time.After(delay(n)):
+		case <-r.Context().Done():
+			return
+		}
+		if n < hops {
+			http.Redirect(w, r, fmt.Sprintf("/hop/%d", n+1), http.StatusFound)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWithPerTryTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		hops    int
+		delay   time.Duration
+		opts    []Option
+		wantErr error
+	}{
+		{
+			name:  "each hop under limit resets the deadline",
+			hops:  3,
+			delay: 60 * time.Millisecond,
+			opts:  []Option{WithPerTryTimeout(150 * time.Millisecond), WithTimeout(2 * time.Second)},
+		},
+		{
+			name:    "single hop over limit",
+			hops:    1,
+			delay:   300 * time.Millisecond,
+			opts:    []Option{WithPerTryTimeout(100 * time.Millisecond)},
+			wantErr: ErrPerTryTimeout,
+		},
+		{
+			name:    "overall timeout bounds the chain",
+			hops:    3,
+			delay:   60 * time.Millisecond,
+			opts:    []Option{WithPerTryTimeout(150 * time.Millisecond), WithTimeout(150 * time.Millisecond)},
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := slowRedirectChain(t, tt.hops, tt.delay)
+			c := NewDefaultClient(tt.opts...)
+
+			body, err := FetchDataFrom(c, srv.URL+"/hop/0")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("FetchDataFrom() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchDataFrom() error = %v", err)
+			}
+			if string(body) != `[]` {
+				t.Errorf("FetchDataFrom() = %q, want []", body)
+			}
+		})
+	}
+}
+
+func TestCheckRedirect_Limit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	_, err := FetchDataFrom(NewDefaultClient(), srv.URL)
+	if err == nil {
+		t.Fatal("FetchDataFrom() expected error for redirect loop")
+	}
+}
+
+func TestWithMaxRedirectTime(t *testing.T) {
+	even := func(int) time.Duration { return 60 * time.Millisecond }
+	slowFirst := func(hop int) time.Duration {
+		if hop == 0 {
+			return 150 * time.Millisecond
+		}
+		return 0
+	}
+	tests := []struct {
+		name    string
+		delay   func(hop int) time.Duration
+		budget  time.Duration
+		wantErr error
+	}{
+		{
+			name:   "chain within budget",
+			delay:  even,
+			budget: 2 * time.Second,
+		},
+		// Each hop is fast enough on its own; the chain as a whole is not.
+		{
+			name:    "chain exceeds budget",
+			delay:   even,
+			budget:  100 * time.Millisecond,
+			wantErr: ErrRedirectTimeExceeded,
+		},
+		{
+			name:    "slow first hop counted",
+			delay:   slowFirst,
+			budget:  100 * time.Millisecond,
+			wantErr: ErrRedirectTimeExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := redirectChain(t, 4, tt.delay)
+			c := NewDefaultClient(WithMaxRedirectTime(tt.budget))
+
+			body, err := FetchDataFrom(c, srv.URL+"/hop/0")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("FetchDataFrom() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || string(body) != `[]` {
+				t.Errorf("FetchDataFrom() = %q, %v; want []", body, err)
+			}
+		})
+	}
+}
+
+func TestWithBodyReadTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		chunks  int
+		gap     time.Duration
+		wantErr bool
+	}{
+		{
+			name:   "body read within limit",
+			chunks: 3,
+			gap:    5 * time.Millisecond,
+		},
+		{
+			name:    "trickled body exceeds limit",
+			chunks:  50,
+			gap:     20 * time.Millisecond,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.(http.Flusher).Flush()
+				for i := 0; i < tt.chunks; i++ {
+					select {
+					case <-time.After(tt.gap):
+					case <-r.Context().Done():
+						return
+					}
+					w.Write([]byte("x"))
+					w.(http.Flusher).Flush()
+				}
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(WithBodyReadTimeout(200 * time.Millisecond))
+			start := time.Now()
+			body, err := FetchDataFrom(c, srv.URL)
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrBodyReadTimeout) {
+					t.Errorf("FetchDataFrom() error = %v, want ErrBodyReadTimeout", err)
+				}
+				if elapsed := time.Since(start); elapsed > time.Second {
+					t.Errorf("FetchDataFrom() took %v, want it cut off near the timeout", elapsed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchDataFrom() error = %v", err)
+			}
+			if len(body) != tt.chunks {
+				t.Errorf("body length = %d, want %d", len(body), tt.chunks)
+			}
+		})
+	}
+}
+
+func TestWithFirstByteTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		delay   time.Duration
+		wantErr bool
+	}{
+		{
+			name:  "body starts in time",
+			delay: 5 * time.Millisecond,
+		},
+		{
+			name:    "body stalls after headers",
+			delay:   5 * time.Second,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.(http.Flusher).Flush()
+				select {
+				case <-time.After(tt.delay):
+				case <-r.Context().Done():
+					return
+				}
+				w.Write([]byte(`[]`))
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(WithFirstByteTimeout(100 * time.Millisecond))
+			start := time.Now()
+			body, err := FetchDataFrom(c, srv.URL)
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrFirstByteTimeout) {
+					t.Errorf("FetchDataFrom() error = %v, want ErrFirstByteTimeout", err)
+				}
+				if elapsed := time.Since(start); elapsed > time.Second {
+					t.Errorf("FetchDataFrom() took %v, want it cut off near the timeout", elapsed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchDataFrom() error = %v", err)
+			}
+			if string(body) != `[]` {
+				t.Errorf("FetchDataFrom() = %q, want %q", body, `[]`)
+			}
+		})
+	}
+}
+
+func TestWithFirstByteTimeout_SlowReader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	c := NewDefaultClient(WithFirstByteTimeout(50 * time.Millisecond))
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+	time.Sleep(150 * time.Millisecond)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(body) != `[]` {
+		t.Errorf("body = %q, want %q", body, `[]`)
+	}
+}
+
+func TestWithStallTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		gaps    []time.Duration
+		wantErr bool
+	}{
+		{
+			name: "steady trickle outlasting the limit",
+			gaps: repeatDuration(15, 20*time.Millisecond),
+		},
+		{
+			name:    "bursts then stall",
+			gaps:    []time.Duration{0, 10 * time.Millisecond, time.Second},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.(http.Flusher).Flush()
+				for _, gap := range tt.gaps {
+					select {
+					case <-time.After(gap):
+					case <-r.Context().Done():
+						return
+					}
+					w.Write([]byte("burst"))
+					w.(http.Flusher).Flush()
+				}
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(WithStallTimeout(100 * time.Millisecond))
+			start := time.Now()
+			body, err := FetchDataFrom(c, srv.URL)
+			if tt.wantErr {
+				if !errors.Is(err, ErrStallTimeout) {
+					t.Fatalf("err = %v, want ErrStallTimeout", err)
+				}
+				if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+					t.Errorf("stall detected after %v, want about 100ms", elapsed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchDataFrom() error = %v", err)
+			}
+			if want := 5 * len(tt.gaps); len(body) != want {
+				t.Errorf("body has %d bytes, want %d", len(body), want)
+			}
+		})
+	}
+}
+
+func repeatDuration(n int, d time.Duration) []time.Duration {
+	out := make([]time.Duration, n)
+	for i := range out {
+		out[i] = d
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+)
+
+// ErrTLSDowngrade is returned when the negotiated TLS version is below the
+// minimum configured with WithTLSMinVersion.
+var ErrTLSDowngrade = errors.New("negotiated TLS version below minimum")
+
+// WithTLSMinVersion sets the minimum accepted TLS version (e.g.
+// tls.VersionTLS12). The negotiated version is also checked after the
+// handshake so a middlebox forcing a downgrade fails the request. A
+// VerifyConnection already set on the TLS config still runs after the
+// check.
+func WithTLSMinVersion(version uint16) Option {
+	return func(c *DefaultClient) {
+		cfg := c.tlsConfig()
+		cfg.MinVersion = version
+		cfg.VerifyConnection = verifyMinVersion(version, cfg.VerifyConnection)
+	}
+}
+
+// verifyMinVersion checks the negotiated version against min, then calls
+// next, if any.
+func verifyMinVersion(min uint16, next func(tls.ConnectionState) error) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		if cs.Version < min {
+			return fmt.Errorf("%w: got %s, want at least %s",
+				ErrTLSDowngrade, tls.VersionName(cs.Version), tls.VersionName(min))
+		}
+		if next != nil {
+			return next(cs)
+		}
+		return nil
+	}
+}
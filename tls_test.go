@@ -0,0 +1,101 @@
+package client
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTLSServer(t *testing.T, maxVersion uint16) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	srv.TLS = &tls.Config{MaxVersion: maxVersion}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func trustServer(c *DefaultClient, srv *httptest.Server) {
+	c.tlsConfig().RootCAs = srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+}
+
+func TestWithTLSMinVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		serverMax uint16
+		clientMin uint16
+		wantErr   bool
+	}{
+		{
+			name:      "server meets minimum",
+			serverMax: tls.VersionTLS13,
+			clientMin: tls.VersionTLS12,
+			wantErr:   false,
+		},
+		{
+			name:      "server below minimum",
+			serverMax: tls.VersionTLS12,
+			clientMin: tls.VersionTLS13,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTLSServer(t, tt.serverMax)
+			c := NewDefaultClient(WithTLSMinVersion(tt.clientMin))
+			trustServer(c, srv)
+
+			resp, err := c.Get(srv.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if resp != nil {
+				resp.Body.Close()
+			}
+		})
+	}
+}
+
+func TestVerifyMinVersion(t *testing.T) {
+	verify := verifyMinVersion(tls.VersionTLS13, nil)
+
+	if err := verify(tls.ConnectionState{Version: tls.VersionTLS13}); err != nil {
+		t.Errorf("verify(TLS 1.3) unexpected error = %v", err)
+	}
+
+	err := verify(tls.ConnectionState{Version: tls.VersionTLS12})
+	if !errors.Is(err, ErrTLSDowngrade) {
+		t.Errorf("verify(TLS 1.2) error = %v, want ErrTLSDowngrade", err)
+	}
+}
+
+func TestWithTLSMinVersion_ChainsVerifyConnection(t *testing.T) {
+	errPinned := errors.New("certificate not pinned")
+	var calls int
+	pin := func(c *DefaultClient) {
+		c.tlsConfig().VerifyConnection = func(tls.ConnectionState) error {
+			calls++
+			return errPinned
+		}
+	}
+
+	srv := newTLSServer(t, tls.VersionTLS13)
+	c := NewDefaultClient(pin, WithTLSMinVersion(tls.VersionTLS12))
+	trustServer(c, srv)
+
+	resp, err := c.Get(srv.URL)
+	if err == nil {
+		resp.Body.Close()
+	}
+	if !errors.Is(err, errPinned) {
+		t.Errorf("Get() error = %v, want the existing VerifyConnection error", err)
+	}
+	if calls != 1 {
+		t.Errorf("existing VerifyConnection called %d times, want 1", calls)
+	}
+}
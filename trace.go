@@ -0,0 +1,30 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+)
+
+// WithConnReuseCallback calls fn once per request reporting whether the
+// request reused a pooled connection. Useful for diagnosing keep-alive
+// problems.
+func WithConnReuseCallback(fn func(reused bool)) Option {
+	return func(c *DefaultClient) {
+		c.connReuseCallback = fn
+	}
+}
+
+func (c *DefaultClient) traceRequest(req *http.Request) *http.Request {
+	if c.connReuseCallback == nil {
+		return req
+	}
+
+	var once sync.Once
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			once.Do(func() { c.connReuseCallback(info.Reused) })
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestWithConnReuseCallback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	var (
+		mu  sync.Mutex
+		got []bool
+	)
+	c := NewDefaultClient(WithConnReuseCallback(func(reused bool) {
+		mu.Lock()
+		got = append(got, reused)
+		mu.Unlock()
+	}))
+
+	for i := 0; i < 2; i++ {
+		if _, err := FetchDataFrom(c, srv.URL); err != nil {
+			t.Fatalf("FetchDataFrom() request %d error = %v", i+1, err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []bool{false, true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reuse reports = %v, want %v", got, want)
+	}
+}
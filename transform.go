@@ -0,0 +1,134 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Stage is a position in the response pipeline. Stages run in order, each
+// running the client's built-in transformers before those added with
+// WithTransformer.
+type Stage int
+
+const (
+	// StageDecompress decodes the body's content coding, then enforces
+	// WithMaxResponseBytes on the decoded bytes.
+	StageDecompress Stage = iota
+	// StageCharset converts the body to another character set. The client
+	// has no built-in charset transformer.
+	StageCharset
+	// StageIntercept runs interceptors such as WithResponseTap.
+	StageIntercept
+	// StageValidate checks the body: response signatures, JSON schema and
+	// UTF-8 validation.
+	StageValidate
+
+	numStages
+)
+
+func (s Stage) String() string {
+	switch s {
+	case StageDecompress:
+		return "decompress"
+	case StageCharset:
+		return "charset"
+	case StageIntercept:
+		return "intercept"
+	case StageValidate:
+		return "validate"
+	}
+	return fmt.Sprintf("Stage(%d)", int(s))
+}
+
+// Transformer is a step in the response pipeline. It typically wraps
+// resp.Body; an error aborts the pipeline and fails the request.
+type Transformer interface {
+	Transform(resp *http.Response) error
+}
+
+// TransformerFunc adapts a function to a Transformer.
+type TransformerFunc func(resp *http.Response) error
+
+func (f TransformerFunc) Transform(resp *http.Response) error {
+	return f(resp)
+}
+
+// TransformError is returned when a response pipeline stage fails.
+type TransformError struct {
+	Stage Stage
+	Err   error
+}
+
+func (e *TransformError) Error() string {
+	return fmt.Sprintf("response %s stage failed: %v", e.Stage, e.Err)
+}
+
+func (e *TransformError) Unwrap() error {
+	return e.Err
+}
+
+// WithTransformer adds t to the response pipeline at stage, after that
+// stage's built-in transformers and any added before it. If stage is not
+// one of the Stage constants, every request fails with ErrInvalidOption.
+func WithTransformer(stage Stage, t Transformer) Option {
+	return func(c *DefaultClient) {
+		if stage < 0 || stage >= numStages {
+			c.invalidOption(fmt.Errorf("transformer stage %d", int(stage)))
+			return
+		}
+		c.transformers[stage] = append(c.transformers[stage], t)
+	}
+}
+
+// transform runs the response pipeline on resp.
+func (c *DefaultClient) transform(req *http.Request, resp *http.Response, compressed bool) error {
+	builtin := c.builtinTransformers(req, compressed)
+	for stage := StageDecompress; stage < numStages; stage++ {
+		for _, stages := range [][]Transformer{builtin[stage], c.transformers[stage]} {
+			for _, t := range stages {
+				if err := t.Transform(resp); err != nil {
+					return &TransformError{Stage: stage, Err: err}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *DefaultClient) builtinTransformers(req *http.Request, compressed bool) [numStages][]Transformer {
+	var b [numStages][]Transformer
+	add := func(stage Stage, fn func(resp *http.Response)) {
+		b[stage] = append(b[stage], TransformerFunc(func(resp *http.Response) error {
+			fn(resp)
+			return nil
+		}))
+	}
+	if compressed {
+		add(StageDecompress, func(resp *http.Response) { c.decompress(req, resp) })
+	}
+	if c.maxResponseBytes > 0 {
+		add(StageDecompress, func(resp *http.Response) {
+			resp.Body = &limitedBody{rc: resp.Body, max: c.maxResponseBytes}
+		})
+	}
+	if c.responseTap != nil {
+		add(StageIntercept, func(resp *http.Response) {
+			resp.Body = &tapBody{Reader: io.TeeReader(resp.Body, c.responseTap), rc: resp.Body}
+		})
+	}
+	if c.signatureVerifier != nil {
+		add(StageValidate, func(resp *http.Response) {
+			resp.Body = &verifyingBody{rc: resp.Body, header: resp.Header, verify: c.signatureVerifier}
+		})
+	}
+	add(StageValidate, func(resp *http.Response) {
+		if c.validatesSchema(resp) {
+			resp.Body = &verifyingBody{rc: resp.Body, header: resp.Header, verify: c.validateSchema}
+		}
+		if c.validatesUTF8(resp) {
+			resp.Body = &utf8Body{rc: resp.Body}
+		}
+	})
+	return b
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithTransformer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(gzipBytes(t, []byte("hello")))
+	}))
+	defer srv.Close()
+
+	errStage := errors.New("rejected")
+	tests := []struct {
+		name      string
+		failAt    Stage // -1 for no failure
+		wantOrder string
+	}{
+		{
+			name:      "stages run in order",
+			failAt:    -1,
+			wantOrder: "decompress,charset,intercept,validate",
+		},
+		{
+			name:      "failing stage aborts",
+			failAt:    StageCharset,
+			wantOrder: "decompress,charset",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var order []string
+			stage := func(s Stage) Option {
+				return WithTransformer(s, TransformerFunc(func(resp *http.Response) error {
+					order = append(order, s.String())
+					if s == StageDecompress {
+						// The built-in decoder has already run.
+						b, err := io.ReadAll(resp.Body)
+						if err != nil || string(b) != "hello" {
+							t.Errorf("body at decompress stage = %q, %v; want decoded", b, err)
+						}
+						resp.Body = io.NopCloser(strings.NewReader(string(b)))
+					}
+					if s == tt.failAt {
+						return errStage
+					}
+					return nil
+				}))
+			}
+			// Registered out of order: stages, not registration, decide.
+			c := NewDefaultClient(
+				WithEncodingPreferences([]EncodingPref{{Encoding: "gzip", Q: 1}}),
+				stage(StageValidate), stage(StageIntercept), stage(StageCharset), stage(StageDecompress),
+			)
+			body, err := FetchDataFrom(c, srv.URL)
+			if got := strings.Join(order, ","); got != tt.wantOrder {
+				t.Errorf("order = %s, want %s", got, tt.wantOrder)
+			}
+			if tt.failAt < 0 {
+				if err != nil || string(body) != "hello" {
+					t.Errorf("FetchDataFrom = %q, %v; want %q", body, err, "hello")
+				}
+				return
+			}
+			var terr *TransformError
+			if !errors.As(err, &terr) || terr.Stage != tt.failAt || !errors.Is(err, errStage) {
+				t.Fatalf("err = %v, want a *TransformError at stage %s", err, tt.failAt)
+			}
+			if !strings.Contains(err.Error(), "charset stage failed") {
+				t.Errorf("err = %q, want it to name the stage", err)
+			}
+		})
+	}
+
+	t.Run("invalid stage fails requests", func(t *testing.T) {
+		c := NewDefaultClient(WithTransformer(Stage(42), TransformerFunc(func(*http.Response) error { return nil })))
+		if _, err := c.Get("http://127.0.0.1:1/"); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("Get() error = %v, want ErrInvalidOption", err)
+		}
+	})
+}
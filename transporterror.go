@@ -0,0 +1,142 @@
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+)
+
+// Phase identifies the stage of a request in which a transport failure
+// occurred.
+type Phase int
+
+const (
+	PhaseDial Phase = iota + 1
+	PhaseTLSHandshake
+	PhaseRequestWrite
+	PhaseResponseHeader
+	PhaseResponseBody
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseDial:
+		return "dial"
+	case PhaseTLSHandshake:
+		return "tls handshake"
+	case PhaseRequestWrite:
+		return "request write"
+	case PhaseResponseHeader:
+		return "response header"
+	case PhaseResponseBody:
+		return "response body"
+	}
+	return fmt.Sprintf("Phase(%d)", int(p))
+}
+
+// TransportError is a network-level failure annotated with the phase of the
+// request in which it happened.
+type TransportError struct {
+	Phase Phase
+	Err   error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("%s failed: %v", e.Phase, e.Err)
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// phaseTracker follows a round trip through httptrace callbacks.
+type phaseTracker struct {
+	mu    sync.Mutex
+	state phaseState
+}
+
+type phaseState struct {
+	tlsStarted bool
+	tlsDone    bool
+	connected  bool
+	wrote      bool
+}
+
+func (p *phaseTracker) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			// Start of a new round trip, e.g. the next redirect hop.
+			p.mu.Lock()
+			p.state = phaseState{}
+			p.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			p.mu.Lock()
+			p.state.tlsStarted = true
+			p.mu.Unlock()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			p.mu.Lock()
+			p.state.tlsDone = err == nil
+			p.mu.Unlock()
+		},
+		GotConn: func(httptrace.GotConnInfo) {
+			p.mu.Lock()
+			p.state.connected = true
+			p.mu.Unlock()
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			p.mu.Lock()
+			p.state.wrote = info.Err == nil
+			p.mu.Unlock()
+		},
+	}
+}
+
+func (p *phaseTracker) phase() Phase {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case !p.state.connected && p.state.tlsStarted && !p.state.tlsDone:
+		return PhaseTLSHandshake
+	case !p.state.connected:
+		return PhaseDial
+	case !p.state.wrote:
+		return PhaseRequestWrite
+	}
+	return PhaseResponseHeader
+}
+
+// sendOnce performs a single round trip, reporting failures as
+// *TransportError.
+func (c *DefaultClient) sendOnce(req *http.Request) (*http.Response, error) {
+	tracker := &phaseTracker{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), tracker.trace()))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, &TransportError{Phase: tracker.phase(), Err: err}
+	}
+	resp.Body = &phaseBody{rc: resp.Body}
+	return resp, nil
+}
+
+// phaseBody reports body read failures as PhaseResponseBody errors.
+type phaseBody struct {
+	rc io.ReadCloser
+}
+
+func (b *phaseBody) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	if err != nil && err != io.EOF {
+		err = &TransportError{Phase: PhaseResponseBody, Err: err}
+	}
+	return n, err
+}
+
+func (b *phaseBody) Close() error {
+	return b.rc.Close()
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("body source failed") }
+
+func hangUp(w http.ResponseWriter) {
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err == nil {
+		conn.Close()
+	}
+}
+
+func TestTransportErrorPhase(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T) (*DefaultClient, *http.Request)
+		want    Phase
+		useBody bool
+	}{
+		{
+			name: "dial",
+			setup: func(t *testing.T) (*DefaultClient, *http.Request) {
+				ln, err := net.Listen("tcp", "127.0.0.1:0")
+				if err != nil {
+					t.Fatal(err)
+				}
+				addr := ln.Addr().String()
+				ln.Close()
+				req, _ := http.NewRequest(http.MethodGet, "http://"+addr, nil)
+				return NewDefaultClient(), req
+			},
+			want: PhaseDial,
+		},
+		{
+			name: "tls handshake",
+			setup: func(t *testing.T) (*DefaultClient, *http.Request) {
+				srv := httptest.NewTLSServer(http.NotFoundHandler())
+				t.Cleanup(srv.Close)
+				req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+				return NewDefaultClient(), req
+			},
+			want: PhaseTLSHandshake,
+		},
+		{
+			name: "request write",
+			setup: func(t *testing.T) (*DefaultClient, *http.Request) {
+				srv := httptest.NewServer(http.NotFoundHandler())
+				t.Cleanup(srv.Close)
+				req, _ := http.NewRequest(http.MethodPost, srv.URL, io.NopCloser(failingReader{}))
+				return NewDefaultClient(), req
+			},
+			want: PhaseRequestWrite,
+		},
+		{
+			name: "response header",
+			setup: func(t *testing.T) (*DefaultClient, *http.Request) {
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					hangUp(w)
+				}))
+				t.Cleanup(srv.Close)
+				req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+				return NewDefaultClient(), req
+			},
+			want: PhaseResponseHeader,
+		},
+		{
+			name: "response body",
+			setup: func(t *testing.T) (*DefaultClient, *http.Request) {
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Length", "100")
+					w.Write([]byte(strings.Repeat("x", 10)))
+					w.(http.Flusher).Flush()
+					hangUp(w)
+				}))
+				t.Cleanup(srv.Close)
+				req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+				return NewDefaultClient(), req
+			},
+			want:    PhaseResponseBody,
+			useBody: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, req := tt.setup(t)
+			resp, err := c.Do(req)
+			if tt.useBody {
+				if err != nil {
+					t.Fatalf("Do() error = %v", err)
+				}
+				_, err = io.ReadAll(resp.Body)
+				resp.Body.Close()
+			}
+
+			var te *TransportError
+			if !errors.As(err, &te) {
+				t.Fatalf("error = %v, want *TransportError", err)
+			}
+			if te.Phase != tt.want {
+				t.Errorf("Phase = %v, want %v", te.Phase, tt.want)
+			}
+		})
+	}
+}
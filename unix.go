@@ -0,0 +1,16 @@
+package client
+
+import (
+	"context"
+	"net"
+)
+
+// WithUnixSocket routes every connection to the Unix domain socket at path,
+// regardless of the URL host, e.g. FetchDataFrom(c, "http://unix/v1.41/info").
+func WithUnixSocket(path string) Option {
+	return func(c *DefaultClient) {
+		c.dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return c.dialer.DialContext(ctx, "unix", path)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithUnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "daemon.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1.41/info" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"ID":"daemon"}`))
+	}))
+	srv.Listener = l
+	srv.Start()
+	defer srv.Close()
+
+	c := NewDefaultClient(WithUnixSocket(sock))
+	body, err := FetchDataFrom(c, "http://unix/v1.41/info")
+	if err != nil {
+		t.Fatalf("FetchDataFrom() error = %v", err)
+	}
+	if string(body) != `{"ID":"daemon"}` {
+		t.Errorf("FetchDataFrom() = %q, want %q", body, `{"ID":"daemon"}`)
+	}
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// UTF8Error is returned while reading a response body that is not valid
+// UTF-8 when WithUTF8Validation is set.
+type UTF8Error struct {
+	// Offset is the byte offset of the first invalid sequence in the body.
+	Offset int64
+}
+
+func (e *UTF8Error) Error() string {
+	return fmt.Sprintf("response body is not valid UTF-8 at byte offset %d", e.Offset)
+}
+
+// WithUTF8Validation checks the body of every successful text response is
+// valid UTF-8 as it is read, failing the read with a *UTF8Error at the first
+// invalid sequence. Binary content types and text declaring another charset
+// are not validated.
+func WithUTF8Validation() Option {
+	return func(c *DefaultClient) {
+		c.validateUTF8 = true
+	}
+}
+
+// validatesUTF8 reports whether the body of resp should be checked.
+func (c *DefaultClient) validatesUTF8(resp *http.Response) bool {
+	if !c.validateUTF8 || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return false
+	}
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	if charset := params["charset"]; charset != "" && !strings.EqualFold(charset, "utf-8") {
+		return false
+	}
+	return isTextMediaType(mediaType)
+}
+
+func isTextMediaType(mediaType string) bool {
+	if strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript",
+		"application/x-ndjson", "application/x-www-form-urlencoded":
+		return true
+	}
+	return false
+}
+
+// utf8Body validates the body as it is read. A multi-byte sequence split
+// across reads is held in pending until the rest of it arrives.
+type utf8Body struct {
+	rc      io.ReadCloser
+	offset  int64
+	pending []byte
+}
+
+func (b *utf8Body) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	data := p[:n]
+	if len(b.pending) > 0 {
+		data = append(b.pending, data...)
+	}
+	i := 0
+	for i < len(data) {
+		if data[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			if err == nil && !utf8.FullRune(data[i:]) {
+				break
+			}
+			return n, &UTF8Error{Offset: b.offset + int64(i)}
+		}
+		i += size
+	}
+	b.offset += int64(i)
+	b.pending = append(b.pending[:0:0], data[i:]...)
+	return n, err
+}
+
+func (b *utf8Body) Close() error {
+	return b.rc.Close()
+}
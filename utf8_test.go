@@ -0,0 +1,104 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestWithUTF8Validation(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantOffset  int64 // -1 for no error
+	}{
+		{
+			name:        "valid",
+			contentType: "application/json",
+			body:        `{"name":"héllo 世界"}`,
+			wantOffset:  -1,
+		},
+		{
+			name:        "invalid byte",
+			contentType: "text/plain; charset=utf-8",
+			body:        "héllo\xffworld",
+			wantOffset:  6,
+		},
+		{
+			name:        "overlong encoding",
+			contentType: "application/problem+json",
+			body:        "ab\xc0\xafcd",
+			wantOffset:  2,
+		},
+		{
+			name:        "truncated at end",
+			contentType: "text/plain",
+			body:        "ab世\xe4\xb8",
+			wantOffset:  5,
+		},
+		{
+			name:        "binary content type",
+			contentType: "application/octet-stream",
+			body:        "ab\xff",
+			wantOffset:  -1,
+		},
+		{
+			name:        "other charset",
+			contentType: "text/plain; charset=iso-8859-1",
+			body:        "caf\xe9",
+			wantOffset:  -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			_, err := FetchDataFrom(NewDefaultClient(WithUTF8Validation()), srv.URL)
+			if tt.wantOffset < 0 {
+				if err != nil {
+					t.Fatalf("FetchDataFrom() error = %v", err)
+				}
+				return
+			}
+			var uerr *UTF8Error
+			if !errors.As(err, &uerr) {
+				t.Fatalf("err = %v, want a *UTF8Error", err)
+			}
+			if uerr.Offset != tt.wantOffset {
+				t.Errorf("offset = %d, want %d", uerr.Offset, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestUTF8Body_SplitReads(t *testing.T) {
+	tests := []struct {
+		body       string
+		wantOffset int64
+	}{
+		{body: "a世界b", wantOffset: -1},
+		{body: "a世\xe7\x95x", wantOffset: 4},
+	}
+	for _, tt := range tests {
+		b := &utf8Body{rc: io.NopCloser(iotest.OneByteReader(strings.NewReader(tt.body)))}
+		_, err := io.ReadAll(b)
+		var uerr *UTF8Error
+		switch {
+		case tt.wantOffset < 0 && err != nil:
+			t.Errorf("%q: unexpected error %v", tt.body, err)
+		case tt.wantOffset >= 0 && !errors.As(err, &uerr):
+			t.Errorf("%q: err = %v, want a *UTF8Error", tt.body, err)
+		case uerr != nil && uerr.Offset != tt.wantOffset:
+			t.Errorf("%q: offset = %d, want %d", tt.body, uerr.Offset, tt.wantOffset)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// WithResponseSignatureVerifier runs verify on the raw body and headers of
+// every response once the body has been fully read. A non-nil error fails
+// the read, so the fetch helpers return it instead of the body.
+func WithResponseSignatureVerifier(verify func(body []byte, header http.Header) error) Option {
+	return func(c *DefaultClient) {
+		c.signatureVerifier = verify
+	}
+}
+
+// verifyingBody buffers what is read and runs verify when the body hits EOF.
+type verifyingBody struct {
+	rc     io.ReadCloser
+	header http.Header
+	verify func(body []byte, header http.Header) error
+	buf    bytes.Buffer
+}
+
+func (b *verifyingBody) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF {
+		if verr := b.verify(b.buf.Bytes(), b.header); verr != nil {
+			return n, fmt.Errorf("response verification failed: %w", verr)
+		}
+	}
+	return n, err
+}
+
+func (b *verifyingBody) Close() error {
+	return b.rc.Close()
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var errBadSignature = errors.New("bad signature")
+
+func sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func hmacVerifier(secret []byte) func([]byte, http.Header) error {
+	return func(body []byte, header http.Header) error {
+		want, err := hex.DecodeString(header.Get("X-Signature"))
+		if err != nil {
+			return errBadSignature
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(body)
+		if !hmac.Equal(mac.Sum(nil), want) {
+			return errBadSignature
+		}
+		return nil
+	}
+}
+
+func TestWithResponseSignatureVerifier(t *testing.T) {
+	secret := []byte("webhook-secret")
+	payload := []byte(`[{"id":1}]`)
+
+	tests := []struct {
+		name      string
+		body      []byte
+		signature string
+		wantErr   bool
+	}{
+		{
+			name:      "valid signature",
+			body:      payload,
+			signature: sign(secret, payload),
+		},
+		{
+			name:      "tampered body",
+			body:      []byte(`[{"id":2}]`),
+			signature: sign(secret, payload),
+			wantErr:   true,
+		},
+		{
+			name:      "missing signature",
+			body:      payload,
+			signature: "",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Signature", tt.signature)
+				w.Write(tt.body)
+			}))
+			defer srv.Close()
+
+			c := NewDefaultClient(WithResponseSignatureVerifier(hmacVerifier(secret)))
+			got, err := FetchDataFrom(c, srv.URL)
+
+			if tt.wantErr {
+				if !errors.Is(err, errBadSignature) {
+					t.Errorf("FetchDataFrom() error = %v, want errBadSignature", err)
+				}
+				if got != nil {
+					t.Errorf("FetchDataFrom() = %q, want nil on error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchDataFrom() unexpected error = %v", err)
+			}
+			if string(got) != string(tt.body) {
+				t.Errorf("FetchDataFrom() = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Warmup opens a pooled connection to the host of each url, concurrently,
+// by sending it a HEAD request, so the first real requests skip the dial
+// and TLS handshake. Any response warms the connection. A host that cannot
+// be reached does not stop the others; the failures are returned joined.
+func (c *DefaultClient) Warmup(ctx context.Context, urls ...string) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, url := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.warm(ctx, url); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("warmup %s: %w", url, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (c *DefaultClient) warm(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
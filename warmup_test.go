@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWarmup(t *testing.T) {
+	var opened, heads atomic.Int64
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			heads.Add(1)
+		}
+		w.Write([]byte("ok"))
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			opened.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	c := NewDefaultClient()
+	if err := c.Warmup(context.Background(), srv.URL, unreachable.URL); err == nil {
+		t.Error("Warmup reported no error for an unreachable host")
+	}
+	if heads.Load() != 1 || opened.Load() != 1 {
+		t.Fatalf("after warmup: %d HEAD requests on %d connections, want 1 on 1", heads.Load(), opened.Load())
+	}
+
+	if _, err := FetchDataFrom(c, srv.URL); err != nil {
+		t.Fatalf("FetchDataFrom() error = %v", err)
+	}
+	if got := opened.Load(); got != 1 {
+		t.Errorf("connections opened = %d, want the warm one reused", got)
+	}
+}
+
+func TestWarmup_Cancelled(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewDefaultClient().Warmup(ctx, srv.URL); err == nil {
+		t.Error("Warmup with a cancelled context reported no error")
+	}
+}